@@ -1,22 +1,128 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"image/color"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"gioui.org/app"
 	"gioui.org/unit"
 	"github.com/bmj2728/catfetch/pkg/shared/api"
 	_ "github.com/bmj2728/catfetch/pkg/shared/api"
+	"github.com/bmj2728/catfetch/pkg/shared/catpic"
+	"github.com/bmj2728/catfetch/pkg/shared/openext"
 	"github.com/bmj2728/catfetch/pkg/shared/ui"
+	"github.com/g4s8/hexcolor"
 )
 
+// theCatAPIKeyEnv switches the app to thecatapi.com when set
+const theCatAPIKeyEnv = "CATFETCH_THECATAPI_KEY"
+
+// version is set by release builds with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
+	debug := flag.Bool("debug", false, "log every request")
+	batteryAware := flag.Bool("battery-aware", true, "stop prefetching cats on battery or in power saver mode")
+	proxy := flag.String("proxy", "", "proxy URL such as socks5://localhost:1080, HTTP_PROXY and HTTPS_PROXY are used by default")
+	presets := flag.String("presets", "", "JSON file of mood buttons, each with a name and optionally a tag, mono and blurred")
+	saveDir := flag.String("save-dir", "", "folder the save dialog starts in, or where cats are saved without one, ~/Pictures/catfetch by default")
+	afterSave := flag.String("after-save", "none", "what to do with a saved cat: none, reveal in the file manager, open with the default app, or run:<command> such as run:gimp {path}")
+	flatten := flag.String("save-flatten", "", "color such as #ffffff to flatten transparent PNGs onto when saving, kept transparent by default")
+	background := flag.String("background", "none", "what fills the area around the cat: none, solid:#rrggbb, checker or blur")
+	convertNRGBA := flag.Bool("convert-nrgba", false, "convert each cat to NRGBA once when it arrives, for steadier frame times at the cost of a copy")
+	providers := flag.String("providers", "", "comma separated providers to ask in order, of cataas and thecatapi, thecatapi first when "+theCatAPIKeyEnv+" is set")
+	flag.Parse()
+
+	// Info and above by default, the API logs each request at debug level
+	level := slog.LevelInfo
+	if *debug {
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+	ui.SetBatteryAware(*batteryAware)
+	ui.SetConvertNRGBA(*convertNRGBA)
+	ui.SetSaveDir(*saveDir)
+	if *flatten != "" {
+		c, err := hexcolor.Parse(*flatten)
+		if err != nil {
+			log.Fatalf("invalid -save-flatten color %q: %v", *flatten, err)
+		}
+		ui.SetSaveFlatten(color.NRGBA(c))
+	}
+	action, err := openext.ParseAction(*afterSave)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ui.SetAfterSave(action)
+	bg, err := catpic.ParseBackground(*background)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ui.SetBackground(bg)
+	if *presets != "" {
+		p, err := ui.LoadPresets(*presets)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ui.SetPresets(p)
+	}
 
-	// Fetch available tags
+	transport, err := api.NewTransport(*proxy)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Keep images on disk so cats seen before don't hit the network
+	httpClient := &http.Client{Timeout: api.DefaultTimeout, Transport: transport}
+	if dir, err := api.DefaultCacheDir(); err != nil {
+		slog.Warn("HTTP cache disabled", "err", err)
+	} else {
+		httpClient.Transport = api.NewDiskCache(dir, 0, transport)
+	}
+
+	// Prefer The Cat API for larger images and breed info if a key is given,
+	// and fall back to the next provider when one is down
+	userAgent := api.WithUserAgent(api.UserAgent(version))
+	cataas := api.NewClient(httpClient, userAgent)
+	key := os.Getenv(theCatAPIKeyEnv)
+	byName := map[string]api.CatProvider{
+		"cataas":    cataas,
+		"thecatapi": api.NewTheCatAPI(key, httpClient, userAgent),
+	}
+	order := *providers
+	if order == "" {
+		order = "cataas,thecatapi"
+		if key != "" {
+			order = "thecatapi,cataas"
+		}
+	}
+	var chain []api.CatProvider
+	for _, name := range strings.Split(order, ",") {
+		p, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			log.Fatalf("invalid -providers %q: unknown provider %q", order, name)
+		}
+		chain = append(chain, p)
+	}
+	ui.SetProvider(api.NewFallbackChain(chain...))
+
+	// Fetch available tags through the same proxy and cache
 	go func() {
-		api.FetchCAASTags(30 * time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		tags, err := cataas.ListTags(ctx)
+		if err != nil {
+			slog.Warn("Error fetching tags", "err", err)
+			return
+		}
+		api.SetAvailableTags(tags)
 	}()
 
 	// Make a window and run the loop
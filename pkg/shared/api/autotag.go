@@ -0,0 +1,68 @@
+package api
+
+import (
+	"image"
+
+	"github.com/bmj2728/catfetch/pkg/shared/imgutil"
+)
+
+const (
+	// images more than this much wider than tall are "wide", and the reverse "tall"
+	autoTagAspect = 1.25
+
+	autoTagSmallPixels  = 640 * 480
+	autoTagMediumPixels = 1920 * 1080
+	autoTagLargePixels  = 3840 * 2160
+)
+
+// WithAutoTags derives extra tags from each fetched image, see AutoTags
+func WithAutoTags() Option {
+	return func(o *options) {
+		o.autoTags = true
+	}
+}
+
+// AutoTags describes img with tags worked out locally, without asking the
+// server: its dominant color name, "wide", "tall" or "square", "gif" or
+// "still", and "small", "medium", "large" or "huge". format is the name
+// returned by image.Decode. Only the first frame of a gif is looked at.
+func AutoTags(img image.Image, format string) []string {
+	if img == nil {
+		return nil
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil
+	}
+
+	tags := []string{imgutil.ColorName(imgutil.DominantColor(img))}
+
+	switch ratio := float64(w) / float64(h); {
+	case ratio > autoTagAspect:
+		tags = append(tags, "wide")
+	case ratio < 1/autoTagAspect:
+		tags = append(tags, "tall")
+	default:
+		tags = append(tags, "square")
+	}
+
+	if format == "gif" {
+		tags = append(tags, "gif")
+	} else {
+		tags = append(tags, "still")
+	}
+
+	switch pixels := w * h; {
+	case pixels < autoTagSmallPixels:
+		tags = append(tags, "small")
+	case pixels < autoTagMediumPixels:
+		tags = append(tags, "medium")
+	case pixels < autoTagLargePixels:
+		tags = append(tags, "large")
+	default:
+		tags = append(tags, "huge")
+	}
+
+	return tags
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"image"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestAutoTags tests the color, aspect, animation and resolution tags
+func TestAutoTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		img    image.Image
+		format string
+		want   []string
+	}{
+		{"small_wide_still", testutil.CreateColorImage(400, 200, 200, 120, 50), "jpeg", []string{"orange", "wide", "still", "small"}},
+		{"medium_tall_gif", testutil.CreateColorImage(800, 1200, 10, 10, 10), "gif", []string{"black", "tall", "gif", "medium"}},
+		{"large_square", testutil.CreateColorImage(1600, 1600, 250, 250, 250), "png", []string{"white", "square", "still", "large"}},
+		{"huge", testutil.CreateColorImage(4096, 2304, 40, 60, 200), "png", []string{"blue", "wide", "still", "huge"}},
+		{"empty", image.NewRGBA(image.Rectangle{}), "png", nil},
+		{"nil", nil, "png", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, AutoTags(tt.img, tt.format), "tags")
+		})
+	}
+}
+
+// TestClient_AutoTags tests tags are only derived when asked for
+func TestClient_AutoTags(t *testing.T) {
+	server := catServer(t)
+	c := NewClient(nil, WithBaseURL(server.URL))
+
+	_, meta, err := c.RandomCat()
+	testutil.AssertNoError(t, err, "RandomCat should succeed")
+	testutil.AssertEqual(t, 0, len(meta.GetAutoTags()), "no auto tags by default")
+
+	_, meta, err = c.RandomCat(WithAutoTags())
+	testutil.AssertNoError(t, err, "RandomCat should succeed")
+	testutil.AssertEqual(t, 4, len(meta.GetAutoTags()), "auto tags")
+	testutil.AssertEqual(t, []string{"cute", "fluffy"}, meta.GetTags(), "server tags untouched")
+}
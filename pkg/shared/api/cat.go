@@ -1,92 +1,267 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
 	"io"
-	"log"
 	"net/http"
+	"net/url"
+	"slices"
 	"time"
 )
 
-func RequestRandomCat(timeout time.Duration) (image.Image, *CatMetadata, error) {
-	// make some stuff
-	bodyReader := bytes.NewReader(make([]byte, 0))
+var ErrNoImageURL = fmt.Errorf("%w: no image url", ErrMetadata)
+
+// RequestRandomCat fetches the metadata and image of a random cat. Options
+// select the cataas instance to use, filter by tags, and ask for a smaller
+// image.
+func RequestRandomCat(timeout time.Duration, opts ...Option) (image.Image, *CatMetadata, error) {
+	return defaultClient.RandomCat(append(slices.Clone(opts), WithTimeout(timeout))...)
+}
+
+// RequestRandomGIF fetches the metadata and image of a random animated cat,
+// decoded to its first frame. Options work as for RequestRandomCat, except
+// that tags can't be combined with a GIF.
+func RequestRandomGIF(timeout time.Duration, opts ...Option) (image.Image, *CatMetadata, error) {
+	return RequestRandomCat(timeout, append(slices.Clone(opts), WithGIF())...)
+}
+
+// RequestCatSaying fetches a random cat with text drawn over it. The text is
+// styled with WithFontSize and WithFontColor.
+func RequestCatSaying(text string, opts ...Option) (image.Image, *CatMetadata, error) {
+	return defaultClient.CatSaying(text, opts...)
+}
+
+func (c *Client) fetchCat(ctx context.Context, o *options) (*FetchResult, error) {
+	return traced(o, func(requestID string) (*FetchResult, error) {
+		res, err := c.requestRandomCat(ctx, requestID, o)
+		if err != nil {
+			return nil, err
+		}
+		res.Source = SourceCataas
+		return res, nil
+	})
+}
+
+// traced runs a fetch under a new request ID that is sent upstream, logged,
+// and attached to errors
+func traced(o *options, fetch func(requestID string) (*FetchResult, error)) (*FetchResult, error) {
+	requestID := newRequestID()
+	if o.tracing {
+		o.timings = &timingLog{}
+	}
+
+	res, err := fetch(requestID)
+	apiMetrics.observeFetch(err)
+	if err != nil {
+		o.log().Warn("Error fetching cat", "request_id", requestID, "err", err)
+		return nil, wrapRequestError(requestID, err)
+	}
+	res.Metadata.RequestID = requestID
+	if o.timings != nil {
+		res.Timings = o.timings.list()
+	}
+
+	return res, nil
+}
+
+func (c *Client) requestRandomCat(ctx context.Context, requestID string, o *options) (*FetchResult, error) {
 	// first get the metadata in JSON format
 	// the NewCatURL provides a CatURL struct using the caas base - https://cataas.com/cat
+	// unless a base URL or endpoint option overrides it, with any ID, tags and text appended
 	// AsJSON adds the json=true param to the CatURL's param slice
 	// Generate validates and constructs the URL, returning an error if not valid
-	reqURL, err := NewCatURL().AsJSON().Generate()
+	reqURL, err := o.requestURL().AsJSON().Generate()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	fmt.Println(reqURL)
-	client := &http.Client{Timeout: timeout}
+	o.log().Debug("Fetching metadata", "request_id", requestID, "url", reqURL)
+
 	var meta CatMetadata
+	err = c.getJSON(ctx, requestID, o, reqURL, nil, &meta)
+	if err != nil {
+		return nil, err
+	}
+
+	o.log().Debug("Fetching image", "request_id", requestID, "id", meta.ID, "url", meta.URL, "tags", meta.Tags)
 
-	req, err := http.NewRequest(http.MethodGet, reqURL, bodyReader)
+	// mirrors may hand back a URL relative to themselves
+	imgURL, err := resolveImageURL(reqURL, meta.URL)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	// and may drop the scaling params, which would mean downloading the original
+	imgURL, err = o.applyImageParams(imgURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.fetchImage(ctx, requestID, o, imgURL, &meta)
+}
 
-	// make the req
-	resp, err := client.Do(req)
+// getJSON sends a GET with the configured and extra headers, and decodes
+// the JSON response into v
+func (c *Client) getJSON(ctx context.Context, requestID string, o *options, reqURL string, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return nil, nil, err
+		return err
+	}
+	o.setHeaders(req, header, requestID)
+
+	// make the req, retrying transient failures
+	resp, err := o.retry.do(c.httpClientFor(o), req, o)
+	if err != nil {
+		return err
 	}
 	// clean up when done
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-
+			o.log().Warn("Error closing response", "request_id", requestID, "err", err)
 		}
 	}(resp.Body)
 
-	//unmarshall into a metadata struct
-	err = json.NewDecoder(resp.Body).Decode(&meta)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	log.Printf("Fetching image: %v", meta)
+	return decodeJSON(resp.Body, v)
+}
 
-	// now get the actual image
-	imgResp, err := http.Get(meta.URL)
-	if err != nil {
-		return nil, nil, err
+// decodeJSON decodes r into v, telling a malformed response apart from one
+// that failed in transit
+func decodeJSON(r io.Reader, v any) error {
+	err := json.NewDecoder(r).Decode(v)
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return wrapError(ErrMetadata, err)
+	default:
+		return transportError(err)
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
+}
+
+// fetchImage returns the image with meta, from the image cache when one is
+// set and holds it, recording its size in meta. Concurrent fetches of the
+// same image URL share one download.
+func (c *Client) fetchImage(ctx context.Context, requestID string, o *options, imgURL string, meta *CatMetadata) (*FetchResult, error) {
+	cached, ok := o.imageCache.get(imgURL)
+	if ok {
+		o.log().Debug("Image cache hit", "request_id", requestID, "url", imgURL)
+	} else {
+		if err := o.checkImageURL(ctx, imgURL); err != nil {
+			return nil, err
+		}
+		var joined bool
+		var err error
+		cached, joined, err = c.flights.do(ctx, imgURL, func(ctx context.Context) (cachedImage, error) {
+			cached, err := c.downloadImage(ctx, requestID, o, imgURL)
+			if err == nil {
+				o.imageCache.add(imgURL, cached)
+			}
+			return cached, err
+		})
+		if joined {
+			o.log().Debug("Joined download in flight", "request_id", requestID, "url", imgURL)
+		}
 		if err != nil {
-			log.Printf("Error fetching image: %v", err)
+			return nil, err
+		}
+		ok = joined
+	}
+	// an image cached or downloaded for another fetch was checked against
+	// that fetch's limits, which may allow more
+	if ok {
+		if err := o.withinLimits(cached); err != nil {
+			return nil, err
 		}
-	}(imgResp.Body)
+	}
+
+	meta.Size = len(cached.data)
+	if o.autoTags {
+		meta.AutoTags = AutoTags(cached.img, cached.format)
+	}
+
+	mFormat := "image/" + cached.format
+
+	if mFormat == meta.MIMEType {
+		o.log().Debug("Expected format registered", "request_id", requestID, "format", mFormat)
+	} else {
+		o.log().Info("Unexpected format registered", "request_id", requestID, "format", mFormat, "mimetype", meta.MIMEType)
+	}
+
+	return &FetchResult{
+		Image:    cached.img,
+		Bytes:    cached.data,
+		Format:   cached.format,
+		Metadata: meta,
+	}, nil
+}
 
-	// Read in the data
-	respBody, err := io.ReadAll(imgResp.Body)
+// withinLimits checks an image against the body size and decode limits of o
+func (o *options) withinLimits(cached cachedImage) error {
+	if int64(len(cached.data)) > o.maxBody {
+		return fmt.Errorf("%w: body of %d bytes > %d", ErrImageTooLarge, len(cached.data), o.maxBody)
+	}
+	b := cached.img.Bounds()
+	return o.decodeLimits.Check(b.Dx(), b.Dy())
+}
+
+// downloadImage downloads and decodes the image
+func (c *Client) downloadImage(ctx context.Context, requestID string, o *options, imgURL string) (cachedImage, error) {
+	imgReq, err := http.NewRequestWithContext(withImageGuard(ctx, o), http.MethodGet, imgURL, nil)
 	if err != nil {
-		return nil, nil, err
+		return cachedImage{}, err
 	}
+	o.setHeaders(imgReq, nil, requestID)
 
-	// decode the image
-	img, format, err := image.Decode(bytes.NewReader(respBody))
+	imgResp, err := o.retry.do(c.imageClientFor(o), imgReq, o)
 	if err != nil {
-		log.Printf("Error decoding image: %v", err)
-		return nil, nil, err
+		return cachedImage{}, err
 	}
 
-	mFormat := "image/" + format
+	// refuse what the server admits is too big, and don't trust it otherwise
+	if imgResp.ContentLength > o.maxBody {
+		_ = imgResp.Body.Close()
+		return cachedImage{}, fmt.Errorf("%w: body of %d bytes > %d", ErrImageTooLarge, imgResp.ContentLength, o.maxBody)
+	}
+	respBody, err := c.readImage(imgReq, imgResp, o)
+	if err != nil {
+		return cachedImage{}, err
+	}
 
-	if mFormat == meta.MIMEType {
-		log.Printf("Expected format registered - %s:%s", mFormat, meta.MIMEType)
-	} else {
-		log.Printf("Unexpected format registered: %s:%s", mFormat, meta.MIMEType)
+	// decode the image, checking the header dimensions first
+	img, format, err := DecodeImage(respBody, o.decodeLimits)
+	if err != nil {
+		o.log().Debug("Error decoding image", "request_id", requestID, "err", err)
+		if errors.Is(err, ErrImageTooLarge) {
+			return cachedImage{}, err
+		}
+		return cachedImage{}, wrapError(ErrDecode, err)
 	}
 
-	return img, &meta, nil
+	return cachedImage{img: img, format: format, data: respBody}, nil
+}
+
+// resolveImageURL resolves the image URL from the metadata against the
+// metadata request, so relative URLs point back at the same instance
+func resolveImageURL(reqURL, imgURL string) (string, error) {
+	if imgURL == "" {
+		return "", ErrNoImageURL
+	}
+	base, err := url.Parse(reqURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(imgURL)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
 }
@@ -60,7 +60,7 @@ func TestRequestRandomCat_AllBranches(t *testing.T) {
 		// - Line 110-114: MIME type comparison and logging
 		// - Line 116: Return
 
-		img, meta, err := RequestRandomCat(5 * time.Second)
+		img, meta, err := RequestRandomCat(5*time.Second, allowServer(imageServer.URL))
 
 		testutil.AssertNoError(t, err, "should succeed")
 		testutil.AssertNotNil(t, img, "image should not be nil")
@@ -102,7 +102,7 @@ func TestRequestRandomCat_AllBranches(t *testing.T) {
 		}
 		defer func() { http.DefaultTransport = oldTransport }()
 
-		img, meta, err := RequestRandomCat(5 * time.Second)
+		img, meta, err := RequestRandomCat(5*time.Second, allowServer(imageServer.URL))
 
 		testutil.AssertNoError(t, err, "should succeed")
 		testutil.AssertNotNil(t, img, "image should not be nil")
@@ -147,7 +147,7 @@ func TestRequestRandomCat_AllBranches(t *testing.T) {
 		// 1. Execute first defer on line 68-73 (closes metadata response body)
 		// 2. Execute second defer on line 88-93 (closes image response body)
 
-		img, _, err := RequestRandomCat(5 * time.Second)
+		img, _, err := RequestRandomCat(5*time.Second, allowServer(imageServer.URL))
 		testutil.AssertNoError(t, err, "should succeed")
 		testutil.AssertNotNil(t, img, "image should not be nil")
 
@@ -194,7 +194,7 @@ func TestRequestRandomCat_AllBranches(t *testing.T) {
 		// Line 81: log.Printf("Fetching image: %v", meta)
 		// Line 111: log.Printf("Expected format registered - %s:%s", ...)
 
-		_, _, err := RequestRandomCat(5 * time.Second)
+		_, _, err := RequestRandomCat(5*time.Second, allowServer(imageServer.URL))
 		testutil.AssertNoError(t, err, "should succeed")
 	})
 }
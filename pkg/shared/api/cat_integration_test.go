@@ -1,9 +1,11 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -60,15 +62,13 @@ func TestRequestRandomCat_RealFunction_Success(t *testing.T) {
 			defer metadataServer.Close()
 
 			// Use custom transport to redirect the hardcoded URL to our test server
-			oldTransport := http.DefaultTransport
-			http.DefaultTransport = &redirectTransport{
+			client := NewClient(&http.Client{Transport: &redirectTransport{
 				metadataURL:   metadataServer.URL,
 				realTransport: http.DefaultTransport,
-			}
-			defer func() { http.DefaultTransport = oldTransport }()
+			}})
 
 			// NOW TEST THE ACTUAL FUNCTION!
-			img, meta, err := RequestRandomCat(5 * time.Second)
+			img, meta, err := client.RandomCat(WithTimeout(5*time.Second), allowServer(imageServer.URL))
 
 			// Verify no error
 			testutil.AssertNoError(t, err, "RequestRandomCat should succeed")
@@ -102,23 +102,20 @@ func TestRequestRandomCat_RealFunction_MetadataFetchError(t *testing.T) {
 	defer metadataServer.Close()
 
 	// Redirect to failing server
-	oldTransport := http.DefaultTransport
-	http.DefaultTransport = &redirectTransport{
+	client := NewClient(&http.Client{Transport: &redirectTransport{
 		metadataURL:   metadataServer.URL,
 		realTransport: http.DefaultTransport,
-	}
-	defer func() { http.DefaultTransport = oldTransport }()
+	}})
 
 	// Call the actual function
-	img, meta, err := RequestRandomCat(5 * time.Second)
-
-	// Should get error (but might not - HTTP 500 returns a body that json.Decode will fail on)
-	// The function doesn't check HTTP status codes, only JSON decode errors
-	_ = img
-	_ = meta
-	_ = err
-	// This test demonstrates that RequestRandomCat doesn't check HTTP status codes
-	// It only fails if JSON decode fails
+	img, meta, err := client.RandomCat(WithTimeout(5*time.Second), WithoutRetries())
+
+	// Non-2xx statuses fail before the body is decoded
+	var statusErr *StatusError
+	testutil.AssertTrue(t, errors.As(err, &statusErr), "should fail with a StatusError")
+	testutil.AssertEqual(t, http.StatusInternalServerError, statusErr.StatusCode, "status code")
+	testutil.AssertNil(t, img, "image should be nil on error")
+	testutil.AssertNil(t, meta, "metadata should be nil on error")
 }
 
 // TestRequestRandomCat_RealFunction_MalformedJSON tests JSON parsing errors
@@ -132,15 +129,13 @@ func TestRequestRandomCat_RealFunction_MalformedJSON(t *testing.T) {
 	defer metadataServer.Close()
 
 	// Redirect to test server
-	oldTransport := http.DefaultTransport
-	http.DefaultTransport = &redirectTransport{
+	client := NewClient(&http.Client{Transport: &redirectTransport{
 		metadataURL:   metadataServer.URL,
 		realTransport: http.DefaultTransport,
-	}
-	defer func() { http.DefaultTransport = oldTransport }()
+	}})
 
 	// Call the actual function
-	img, meta, err := RequestRandomCat(5 * time.Second)
+	img, meta, err := client.RandomCat(WithTimeout(5 * time.Second))
 
 	// Should get JSON decode error
 	testutil.AssertError(t, err, "should fail with malformed JSON")
@@ -173,15 +168,13 @@ func TestRequestRandomCat_RealFunction_ImageFetchError(t *testing.T) {
 	defer metadataServer.Close()
 
 	// Redirect to test server
-	oldTransport := http.DefaultTransport
-	http.DefaultTransport = &redirectTransport{
+	client := NewClient(&http.Client{Transport: &redirectTransport{
 		metadataURL:   metadataServer.URL,
 		realTransport: http.DefaultTransport,
-	}
-	defer func() { http.DefaultTransport = oldTransport }()
+	}})
 
 	// Call the actual function
-	img, meta, err := RequestRandomCat(5 * time.Second)
+	img, meta, err := client.RandomCat(WithTimeout(5*time.Second), allowServer(imageServer.URL))
 
 	// Should fail when trying to decode the image (404 response isn't a valid image)
 	testutil.AssertError(t, err, "should fail with bad image data")
@@ -216,15 +209,13 @@ func TestRequestRandomCat_RealFunction_CorruptedImage(t *testing.T) {
 	defer metadataServer.Close()
 
 	// Redirect to test server
-	oldTransport := http.DefaultTransport
-	http.DefaultTransport = &redirectTransport{
+	client := NewClient(&http.Client{Transport: &redirectTransport{
 		metadataURL:   metadataServer.URL,
 		realTransport: http.DefaultTransport,
-	}
-	defer func() { http.DefaultTransport = oldTransport }()
+	}})
 
 	// Call the actual function
-	img, meta, err := RequestRandomCat(5 * time.Second)
+	img, meta, err := client.RandomCat(WithTimeout(5*time.Second), allowServer(imageServer.URL))
 
 	// Should fail when trying to decode corrupted image
 	testutil.AssertError(t, err, "should fail with corrupted image")
@@ -270,15 +261,13 @@ func TestRequestRandomCat_RealFunction_Timeout(t *testing.T) {
 	defer metadataServer.Close()
 
 	// Redirect to test server
-	oldTransport := http.DefaultTransport
-	http.DefaultTransport = &redirectTransport{
+	client := NewClient(&http.Client{Transport: &redirectTransport{
 		metadataURL:   metadataServer.URL,
 		realTransport: http.DefaultTransport,
-	}
-	defer func() { http.DefaultTransport = oldTransport }()
+	}})
 
 	// Call with short timeout (1 second, less than the 5 second sleep)
-	img, meta, err := RequestRandomCat(1 * time.Second)
+	img, meta, err := client.RandomCat(WithTimeout(1*time.Second), allowServer(imageServer.URL))
 
 	// Should timeout
 	testutil.AssertError(t, err, "should timeout")
@@ -314,15 +303,13 @@ func TestRequestRandomCat_RealFunction_MIMETypeMismatch(t *testing.T) {
 	defer metadataServer.Close()
 
 	// Redirect to test server
-	oldTransport := http.DefaultTransport
-	http.DefaultTransport = &redirectTransport{
+	client := NewClient(&http.Client{Transport: &redirectTransport{
 		metadataURL:   metadataServer.URL,
 		realTransport: http.DefaultTransport,
-	}
-	defer func() { http.DefaultTransport = oldTransport }()
+	}})
 
 	// Call the actual function
-	img, meta, err := RequestRandomCat(5 * time.Second)
+	img, meta, err := client.RandomCat(WithTimeout(5*time.Second), allowServer(imageServer.URL))
 
 	// Should succeed but log the mismatch
 	testutil.AssertNoError(t, err, "should succeed despite mismatch")
@@ -334,6 +321,13 @@ func TestRequestRandomCat_RealFunction_MIMETypeMismatch(t *testing.T) {
 	// The image was successfully decoded as PNG (actual format)
 }
 
+// allowServer lets metadata served for cataas.com point at the test server
+// at serverURL for the image
+func allowServer(serverURL string) Option {
+	u, _ := url.Parse(serverURL)
+	return WithAllowedHosts(u.Hostname())
+}
+
 // redirectTransport is a custom http.RoundTripper that redirects hardcoded URLs to test servers
 type redirectTransport struct {
 	metadataURL   string
@@ -376,15 +370,13 @@ func TestRequestRandomCat_RealFunction_EmptyResponseBody(t *testing.T) {
 	defer metadataServer.Close()
 
 	// Redirect to test server
-	oldTransport := http.DefaultTransport
-	http.DefaultTransport = &redirectTransport{
+	client := NewClient(&http.Client{Transport: &redirectTransport{
 		metadataURL:   metadataServer.URL,
 		realTransport: http.DefaultTransport,
-	}
-	defer func() { http.DefaultTransport = oldTransport }()
+	}})
 
 	// Call the actual function
-	img, meta, err := RequestRandomCat(5 * time.Second)
+	img, meta, err := client.RandomCat(WithTimeout(5 * time.Second))
 
 	// Should fail with JSON decode error (EOF)
 	testutil.AssertError(t, err, "should fail with empty body")
@@ -501,16 +493,14 @@ func TestRequestRandomCat_RealFunction_InvalidTimeout(t *testing.T) {
 	defer metadataServer.Close()
 
 	// Redirect to test server
-	oldTransport := http.DefaultTransport
-	http.DefaultTransport = &redirectTransport{
+	client := NewClient(&http.Client{Transport: &redirectTransport{
 		metadataURL:   metadataServer.URL,
 		realTransport: http.DefaultTransport,
-	}
-	defer func() { http.DefaultTransport = oldTransport }()
+	}})
 
 	// Test with zero timeout (should work - means no timeout)
 	t.Run("zero_timeout", func(t *testing.T) {
-		img, meta, err := RequestRandomCat(0)
+		img, meta, err := client.RandomCat(WithTimeout(0), allowServer(imageServer.URL))
 		// Zero timeout means no timeout in http.Client
 		// Should succeed
 		testutil.AssertNoError(t, err, "zero timeout should work")
@@ -520,7 +510,7 @@ func TestRequestRandomCat_RealFunction_InvalidTimeout(t *testing.T) {
 
 	// Test with negative timeout (treated as zero - no timeout)
 	t.Run("negative_timeout", func(t *testing.T) {
-		img, meta, err := RequestRandomCat(-1 * time.Second)
+		img, meta, err := client.RandomCat(WithTimeout(-1*time.Second), allowServer(imageServer.URL))
 		// Negative timeout is treated as zero
 		testutil.AssertNoError(t, err, "negative timeout should work")
 		testutil.AssertNotNil(t, img, "image should not be nil")
@@ -1,46 +1,108 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"log"
-	"net/http"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const (
-	caasTags = "https://cataas.com/api/tags?json=true" //will return valid tags
-)
+type CAASTags []string
 
-var AvailableTags = CAASTags{}
+// availableTags holds the tags generated URLs are checked against, it is
+// swapped as a whole so fetches can read it while the list loads
+var availableTags atomic.Pointer[CAASTags]
 
-type CAASTags []string
+// AvailableTags returns a copy of the tags generated URLs are checked
+// against, empty until they are loaded
+func AvailableTags() CAASTags {
+	if tags := availableTags.Load(); tags != nil {
+		return slices.Clone(*tags)
+	}
+	return CAASTags{}
+}
+
+// SetAvailableTags replaces the tags generated URLs are checked against. An
+// empty list accepts every tag.
+func SetAvailableTags(tags CAASTags) {
+	tags = slices.Clone(tags)
+	availableTags.Store(&tags)
+}
+
+// DefaultTagsTTL is how long ListTags reuses a fetched tag list
+const DefaultTagsTTL = time.Hour
+
+// tagCache holds the last tag list fetched by a client
+type tagCache struct {
+	mu      sync.Mutex
+	url     string
+	tags    CAASTags
+	fetched time.Time
+}
 
-func FetchCAASTags(timeout time.Duration) {
-	bodyReader := bytes.NewReader(make([]byte, 0))
-	client := &http.Client{Timeout: timeout}
-	req, err := http.NewRequest(http.MethodGet, caasTags, bodyReader)
+// FetchCAASTags loads the valid tags with SetAvailableTags, from cataas.com
+// or the instance given by WithBaseURL
+func FetchCAASTags(timeout time.Duration, opts ...Option) {
+	opts = append(slices.Clone(opts), WithTimeout(timeout))
+	tags, err := ListTags(context.Background(), opts...)
 	if err != nil {
-		fmt.Println(err)
+		newOptions(opts).log().Warn("Error fetching tags", "err", err)
+		return
 	}
+	SetAvailableTags(tags)
+}
 
-	resp, err := client.Do(req)
+// ListTags returns the valid tags, fetching them at most once per TTL
+func ListTags(ctx context.Context, opts ...Option) (CAASTags, error) {
+	return defaultClient.ListTags(ctx, opts...)
+}
+
+// ListTags returns the valid tags, fetching them at most once per TTL. If a
+// refresh fails, the expired list is returned instead of the error.
+func (c *Client) ListTags(ctx context.Context, opts ...Option) (CAASTags, error) {
+	o := c.options(opts)
+	return c.tags.get(o.tagsURL(), o, func() (CAASTags, error) {
+		return c.Tags(ctx, opts...)
+	})
+}
+
+// Tags fetches the valid tags, bypassing the cache
+func (c *Client) Tags(ctx context.Context, opts ...Option) (CAASTags, error) {
+	o := c.options(opts)
+
+	var tags CAASTags
+	err := c.getJSON(ctx, newRequestID(), o, o.tagsURL(), nil, &tags)
 	if err != nil {
-		fmt.Println(err)
+		return nil, err
 	}
-	// clean up when done
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}(resp.Body)
 
-	err = json.NewDecoder(resp.Body).Decode(&AvailableTags)
+	return tags, nil
+}
+
+// get returns the list cached for url while it is younger than the tags
+// TTL, and calls fetch otherwise. If fetch fails, an expired list is
+// returned instead.
+func (tc *tagCache) get(url string, o *options, fetch func() (CAASTags, error)) (CAASTags, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	cached := tc.url == url && tc.tags != nil
+	if cached && o.now().Sub(tc.fetched) < o.tagsTTL {
+		return slices.Clone(tc.tags), nil
+	}
+
+	tags, err := fetch()
 	if err != nil {
-		fmt.Println(err)
+		if cached {
+			o.log().Info("Error refreshing tags, using cached list", "err", err)
+			return slices.Clone(tc.tags), nil
+		}
+		return nil, err
 	}
 
+	tc.url = url
+	tc.tags = tags
+	tc.fetched = o.now()
+	return slices.Clone(tags), nil
 }
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// tagServer serves a tag list, failing with 503 while fail is set
+func tagServer(t *testing.T, fail *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail != nil && fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`["cute","orange","sleepy"]`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// TestClient_ListTags tests caching of the tag list
+func TestClient_ListTags(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("cached_within_ttl", func(t *testing.T) {
+		server, calls := tagServer(t, nil)
+		c := NewClient(nil, WithBaseURL(server.URL))
+
+		tags, err := c.ListTags(ctx)
+		testutil.AssertNoError(t, err, "ListTags should succeed")
+		testutil.AssertEqual(t, 3, len(tags), "tag count")
+
+		_, err = c.ListTags(ctx)
+		testutil.AssertNoError(t, err, "cached ListTags should succeed")
+		testutil.AssertEqual(t, int32(1), calls.Load(), "second call should use the cache")
+	})
+
+	t.Run("refreshes_after_ttl", func(t *testing.T) {
+		server, calls := tagServer(t, nil)
+		c := NewClient(nil, WithBaseURL(server.URL), WithTagsTTL(0))
+
+		c.ListTags(ctx)
+		c.ListTags(ctx)
+		testutil.AssertEqual(t, int32(2), calls.Load(), "expired list should be refetched")
+	})
+
+	t.Run("callers_get_a_copy", func(t *testing.T) {
+		server, _ := tagServer(t, nil)
+		c := NewClient(nil, WithBaseURL(server.URL))
+
+		tags, _ := c.ListTags(ctx)
+		tags[0] = "changed"
+		again, _ := c.ListTags(ctx)
+		testutil.AssertEqual(t, "cute", again[0], "cache should not be modified by callers")
+	})
+
+	t.Run("stale_list_on_error", func(t *testing.T) {
+		var fail atomic.Bool
+		server, _ := tagServer(t, &fail)
+		c := NewClient(nil, WithBaseURL(server.URL), WithTagsTTL(0), WithoutRetries())
+
+		_, err := c.ListTags(ctx)
+		testutil.AssertNoError(t, err, "first fetch should succeed")
+
+		fail.Store(true)
+		tags, err := c.ListTags(ctx)
+		testutil.AssertNoError(t, err, "stale list should be returned")
+		testutil.AssertEqual(t, 3, len(tags), "stale tag count")
+	})
+
+	t.Run("error_without_cache", func(t *testing.T) {
+		var fail atomic.Bool
+		fail.Store(true)
+		server, _ := tagServer(t, &fail)
+		c := NewClient(nil, WithBaseURL(server.URL), WithoutRetries())
+
+		_, err := c.ListTags(ctx)
+		var statusErr *StatusError
+		testutil.AssertTrue(t, errors.As(err, &statusErr), "should fail with a StatusError")
+	})
+
+	t.Run("other_instance_refetches", func(t *testing.T) {
+		first, firstCalls := tagServer(t, nil)
+		second, secondCalls := tagServer(t, nil)
+		c := NewClient(nil)
+
+		c.ListTags(ctx, WithBaseURL(first.URL))
+		c.ListTags(ctx, WithBaseURL(second.URL))
+		testutil.AssertEqual(t, int32(1), firstCalls.Load(), "first instance")
+		testutil.AssertEqual(t, int32(1), secondCalls.Load(), "second instance")
+	})
+
+	t.Run("cancelled_context", func(t *testing.T) {
+		server, _ := tagServer(t, nil)
+		c := NewClient(nil, WithBaseURL(server.URL))
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := c.ListTags(cancelled)
+		testutil.AssertTrue(t, errors.Is(err, context.Canceled), "should fail with the context error")
+	})
+}
+
+// TestFetchCAASTags tests loading AvailableTags
+func TestFetchCAASTags(t *testing.T) {
+	setAvailableTags(t, CAASTags{})
+	server, _ := tagServer(t, nil)
+
+	FetchCAASTags(5*time.Second, WithBaseURL(server.URL))
+	testutil.AssertEqual(t, 3, len(AvailableTags()), "available tags")
+}
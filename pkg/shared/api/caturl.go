@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"slices"
@@ -11,13 +12,15 @@ import (
 )
 
 const (
-	caasBaseURL       = "https://cataas.com/cat"
+	caasBaseURL       = caasHost + caasCatEndpoint
 	caasSaysEndpoint  = "says"
+	caasGIFEndpoint   = "gif"
 	caasQueryStart    = "?"
 	caasQueryAnd      = "&"
 	caasReturnJSON    = "json=true"
 	caasReturnHTML    = "html=true"
 	caasPathSeparator = '/'
+	caasTagSeparator  = ","
 )
 
 const (
@@ -53,10 +56,11 @@ const (
 )
 
 var (
-	ErrIDAndTag    = fmt.Errorf("cannot generate url with id and tag")
-	ErrSaysNoText  = fmt.Errorf("cannot generate a Says URL with no text")
-	ErrInvalidTag  = fmt.Errorf("invalid tag")
-	ErrHTMLAndJSON = fmt.Errorf("cannot generate as both HTML and JSON")
+	ErrIDAndTag    = errors.New("cannot generate url with id and tag")
+	ErrSaysNoText  = errors.New("cannot generate a Says URL with no text")
+	ErrInvalidTag  = errors.New("invalid tag")
+	ErrHTMLAndJSON = errors.New("cannot generate as both HTML and JSON")
+	ErrGIFWithID   = errors.New("cannot generate a GIF url with id or tag")
 )
 
 func validRGBValue(val int) bool {
@@ -66,6 +70,30 @@ func validRGBValue(val int) bool {
 	return true
 }
 
+// validTag reports whether every tag in a comma separated list is known.
+// Until the tags have been fetched every tag is accepted.
+func validTag(tag string) bool {
+	known := availableTags.Load()
+	if known == nil || len(*known) == 0 {
+		return true
+	}
+	for _, t := range strings.Split(tag, caasTagSeparator) {
+		if !slices.Contains(*known, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeTag escapes each tag in a comma separated list for use in the path
+func escapeTag(tag string) string {
+	tags := strings.Split(tag, caasTagSeparator)
+	for i, t := range tags {
+		tags[i] = url.PathEscape(t)
+	}
+	return strings.Join(tags, caasTagSeparator)
+}
+
 type CatURL struct {
 	baseURL      string // will store the base url
 	catID        string
@@ -78,6 +106,7 @@ type CatURL struct {
 	params       []string // store params
 	asJSON       bool
 	asHTML       bool
+	asGIF        bool
 }
 
 /*
@@ -86,6 +115,9 @@ type CatURL struct {
 		- caasBaseURL
 		- caasBaseURL/ID
 		- caasBaseURL/TAG
+	gifCalls:
+		- caasBaseURL/caasGIFEndpoint
+		- caasBaseURL/caasGIFEndpoint/caasSaysEndpoint/escaped%20text%21
 	withTextOverlay:
 		- caasBaseURL/caasSaysEndpoint/escaped%20text%21
 		- caasBaseURL/ID/caasSaysEndpoint/escaped%20text%21
@@ -99,9 +131,15 @@ type CatURL struct {
 		- caasBaseURL/TAG/caasSaysEndpoint/escaped%20text%21 + caasQueryStart + params
 */
 
-func NewCatURL() *CatURL {
+// NewCatURL starts a builder against cataas.com/cat, or the base URL and
+// endpoint given as options
+func NewCatURL(opts ...Option) *CatURL {
+	return newCatURL(newOptions(opts))
+}
+
+func newCatURL(o *options) *CatURL {
 	return &CatURL{
-		baseURL: caasBaseURL,
+		baseURL: o.catURL(),
 		params:  make([]string, 0),
 	}
 }
@@ -125,12 +163,27 @@ func (c *CatURL) WithID(id string) *CatURL {
 		params:       c.params,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
+// WithTag limits the random cat to one tag, e.g. "cute"
 func (c *CatURL) WithTag(tag string) *CatURL {
+	return c.WithTags(tag)
+}
+
+// WithTags limits the random cat to cats carrying all the given tags,
+// mapping to /cat/cute,orange
+func (c *CatURL) WithTags(tags ...string) *CatURL {
+	cleaned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
 
-	if !slices.Contains(AvailableTags, tag) {
+	if len(cleaned) == 0 {
 		return &CatURL{
 			baseURL:      c.baseURL,
 			catID:        c.catID,
@@ -143,6 +196,7 @@ func (c *CatURL) WithTag(tag string) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 
@@ -150,7 +204,7 @@ func (c *CatURL) WithTag(tag string) *CatURL {
 		baseURL:      c.baseURL,
 		catID:        c.catID,
 		hasID:        c.hasID,
-		tag:          tag,
+		tag:          strings.Join(cleaned, caasTagSeparator),
 		hasTag:       true,
 		hasSays:      c.hasSays,
 		saysText:     c.saysText,
@@ -158,11 +212,14 @@ func (c *CatURL) WithTag(tag string) *CatURL {
 		params:       c.params,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
+// WithSays draws text over the cat, mapping to /cat/says/:text
 func (c *CatURL) WithSays(txt string) *CatURL {
-	cleaned := url.QueryEscape(txt)
+	// the text is a path segment, so spaces must become %20 rather than +
+	cleaned := url.PathEscape(txt)
 	return &CatURL{
 		baseURL:      c.baseURL,
 		hasID:        c.hasID,
@@ -175,9 +232,11 @@ func (c *CatURL) WithSays(txt string) *CatURL {
 		params:       c.params,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
+// WithCAASImageType asks cataas for one of its pre-scaled sizes
 func (c *CatURL) WithCAASImageType(imgType CAASImageType) *CatURL {
 	// Get the str repr if it exists
 	str, exists := CAASImageTypes[imgType]
@@ -195,6 +254,7 @@ func (c *CatURL) WithCAASImageType(imgType CAASImageType) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 
@@ -213,9 +273,12 @@ func (c *CatURL) WithCAASImageType(imgType CAASImageType) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
+// WithCAASImageFilter applies a server-side filter. CAASImageFilterCustom
+// enables the rgb, brightness, saturation, hue and lightness params.
 func (c *CatURL) WithCAASImageFilter(filter CAASImageFilter) *CatURL {
 	str, exists := CAASImageFilters[filter]
 	if !exists {
@@ -231,6 +294,7 @@ func (c *CatURL) WithCAASImageFilter(filter CAASImageFilter) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	isCustom := false
@@ -250,6 +314,7 @@ func (c *CatURL) WithCAASImageFilter(filter CAASImageFilter) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -268,6 +333,7 @@ func (c *CatURL) WithCAASImageFit(fit CAASImageFit) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyFit, str)
@@ -283,6 +349,7 @@ func (c *CatURL) WithCAASImageFit(fit CAASImageFit) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -301,6 +368,7 @@ func (c *CatURL) WithCAASImagePosition(position CAASImagePosition) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyPosition, str)
@@ -316,10 +384,29 @@ func (c *CatURL) WithCAASImagePosition(position CAASImagePosition) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
+// WithWidth asks cataas to scale the image to the given width in pixels.
+// Values below 1 are ignored.
 func (c *CatURL) WithWidth(width int) *CatURL {
+	if width < 1 {
+		return &CatURL{
+			baseURL:      c.baseURL,
+			catID:        c.catID,
+			hasID:        c.hasID,
+			tag:          c.tag,
+			hasTag:       c.hasTag,
+			hasSays:      c.hasSays,
+			saysText:     c.saysText,
+			customFilter: c.customFilter,
+			params:       c.params,
+			asJSON:       c.asJSON,
+			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
+		}
+	}
 	updatedParams := c.updateParams(caasKeyWidth, strconv.Itoa(width))
 	return &CatURL{
 		baseURL:      c.baseURL,
@@ -333,10 +420,29 @@ func (c *CatURL) WithWidth(width int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
+// WithHeight asks cataas to scale the image to the given height in pixels.
+// Values below 1 are ignored.
 func (c *CatURL) WithHeight(height int) *CatURL {
+	if height < 1 {
+		return &CatURL{
+			baseURL:      c.baseURL,
+			catID:        c.catID,
+			hasID:        c.hasID,
+			tag:          c.tag,
+			hasTag:       c.hasTag,
+			hasSays:      c.hasSays,
+			saysText:     c.saysText,
+			customFilter: c.customFilter,
+			params:       c.params,
+			asJSON:       c.asJSON,
+			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
+		}
+	}
 	updatedParams := c.updateParams(caasKeyHeight, strconv.Itoa(height))
 	return &CatURL{
 		baseURL:      c.baseURL,
@@ -350,10 +456,28 @@ func (c *CatURL) WithHeight(height int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
+// WithBlur blurs the cat server-side. Values below 1 are ignored.
 func (c *CatURL) WithBlur(blur int) *CatURL {
+	if blur < 1 {
+		return &CatURL{
+			baseURL:      c.baseURL,
+			catID:        c.catID,
+			hasID:        c.hasID,
+			tag:          c.tag,
+			hasTag:       c.hasTag,
+			hasSays:      c.hasSays,
+			saysText:     c.saysText,
+			customFilter: c.customFilter,
+			params:       c.params,
+			asJSON:       c.asJSON,
+			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
+		}
+	}
 	updatedParams := c.updateParams(caasKeyBlur, strconv.Itoa(blur))
 	return &CatURL{
 		baseURL:      c.baseURL,
@@ -367,6 +491,7 @@ func (c *CatURL) WithBlur(blur int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -384,6 +509,7 @@ func (c *CatURL) WithFilterR(r int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyRed, strconv.Itoa(r))
@@ -399,6 +525,7 @@ func (c *CatURL) WithFilterR(r int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -416,6 +543,7 @@ func (c *CatURL) WithFilterG(g int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyGreen, strconv.Itoa(g))
@@ -431,6 +559,7 @@ func (c *CatURL) WithFilterG(g int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -448,6 +577,7 @@ func (c *CatURL) WithFilterB(b int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyBlue, strconv.Itoa(b))
@@ -463,6 +593,7 @@ func (c *CatURL) WithFilterB(b int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -481,6 +612,7 @@ func (c *CatURL) WithFilterRGB(r, g, b int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 
@@ -497,6 +629,7 @@ func (c *CatURL) WithFilterRGB(r, g, b int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 
@@ -518,6 +651,7 @@ func (c *CatURL) WithFilterRGB(r, g, b int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -535,6 +669,7 @@ func (c *CatURL) WithBrightness(brightness int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyBrightness, strconv.Itoa(brightness))
@@ -550,6 +685,7 @@ func (c *CatURL) WithBrightness(brightness int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -567,6 +703,7 @@ func (c *CatURL) WithSaturation(saturation int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeySaturation, strconv.Itoa(saturation))
@@ -582,6 +719,7 @@ func (c *CatURL) WithSaturation(saturation int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -599,6 +737,7 @@ func (c *CatURL) WithHue(hue int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyHue, strconv.Itoa(hue))
@@ -614,6 +753,7 @@ func (c *CatURL) WithHue(hue int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -631,6 +771,7 @@ func (c *CatURL) WithLightness(lightness int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyLightness, strconv.Itoa(lightness))
@@ -646,6 +787,7 @@ func (c *CatURL) WithLightness(lightness int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -663,6 +805,7 @@ func (c *CatURL) WithFont(font CAASFont) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	str, exists := CAASFonts[font]
@@ -679,6 +822,7 @@ func (c *CatURL) WithFont(font CAASFont) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyFont, str)
@@ -694,6 +838,7 @@ func (c *CatURL) WithFont(font CAASFont) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -711,6 +856,7 @@ func (c *CatURL) WithFontSize(size int) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 	updatedParams := c.updateParams(caasKeyFontSize, strconv.Itoa(size))
@@ -726,6 +872,7 @@ func (c *CatURL) WithFontSize(size int) *CatURL {
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -743,6 +890,7 @@ func (c *CatURL) WithFontColor(hexColor string) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 
@@ -760,20 +908,25 @@ func (c *CatURL) WithFontColor(hexColor string) *CatURL {
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
 
-	updatedParams := c.updateParams(caasKeyFontColor, hexColor)
+	// escaped so the leading # isn't read as a fragment
+	updatedParams := c.updateParams(caasKeyFontColor, url.QueryEscape(hexColor))
 	return &CatURL{
 		baseURL:      c.baseURL,
 		catID:        c.catID,
+		hasID:        c.hasID,
 		tag:          c.tag,
+		hasTag:       c.hasTag,
 		hasSays:      c.hasSays,
 		saysText:     c.saysText,
 		customFilter: c.customFilter,
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -782,26 +935,32 @@ func (c *CatURL) WithFontBackground(hexColor string) *CatURL {
 		return &CatURL{
 			baseURL:      c.baseURL,
 			catID:        c.catID,
+			hasID:        c.hasID,
 			tag:          c.tag,
+			hasTag:       c.hasTag,
 			hasSays:      c.hasSays,
 			saysText:     c.saysText,
 			customFilter: c.customFilter,
 			params:       c.params,
 			asJSON:       c.asJSON,
 			asHTML:       c.asHTML,
+			asGIF:        c.asGIF,
 		}
 	}
-	updatedParams := c.updateParams(caasKeyFontBackground, hexColor)
+	updatedParams := c.updateParams(caasKeyFontBackground, url.QueryEscape(hexColor))
 	return &CatURL{
 		baseURL:      c.baseURL,
 		catID:        c.catID,
+		hasID:        c.hasID,
 		tag:          c.tag,
+		hasTag:       c.hasTag,
 		hasSays:      c.hasSays,
 		saysText:     c.saysText,
 		customFilter: c.customFilter,
 		params:       updatedParams,
 		asJSON:       c.asJSON,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -809,13 +968,16 @@ func (c *CatURL) AsJSON() *CatURL {
 	return &CatURL{
 		baseURL:      c.baseURL,
 		catID:        c.catID,
+		hasID:        c.hasID,
 		tag:          c.tag,
+		hasTag:       c.hasTag,
 		hasSays:      c.hasSays,
 		saysText:     c.saysText,
 		customFilter: c.customFilter,
 		params:       c.params,
 		asJSON:       true,
 		asHTML:       c.asHTML,
+		asGIF:        c.asGIF,
 	}
 }
 
@@ -823,13 +985,35 @@ func (c *CatURL) AsHTML() *CatURL {
 	return &CatURL{
 		baseURL:      c.baseURL,
 		catID:        c.catID,
+		hasID:        c.hasID,
 		tag:          c.tag,
+		hasTag:       c.hasTag,
 		hasSays:      c.hasSays,
 		saysText:     c.saysText,
 		customFilter: c.customFilter,
 		params:       c.params,
 		asJSON:       c.asJSON,
 		asHTML:       true,
+		asGIF:        c.asGIF,
+	}
+}
+
+// AsGIF asks for an animated cat, mapping to /cat/gif. cataas picks GIFs
+// at random only, so it can't be combined with an ID or tags.
+func (c *CatURL) AsGIF() *CatURL {
+	return &CatURL{
+		baseURL:      c.baseURL,
+		catID:        c.catID,
+		hasID:        c.hasID,
+		tag:          c.tag,
+		hasTag:       c.hasTag,
+		hasSays:      c.hasSays,
+		saysText:     c.saysText,
+		customFilter: c.customFilter,
+		params:       c.params,
+		asJSON:       c.asJSON,
+		asHTML:       c.asHTML,
+		asGIF:        true,
 	}
 }
 
@@ -842,25 +1026,32 @@ func (c *CatURL) Generate() (string, error) {
 	if c.hasSays && c.saysText == "" {
 		return "", ErrSaysNoText
 	}
-	if c.hasTag && slices.Contains(AvailableTags, c.tag) {
+	if c.hasTag && !validTag(c.tag) {
 		return "", ErrInvalidTag
 	}
 	if c.asHTML && c.asJSON {
 		return "", ErrHTMLAndJSON
 	}
+	if c.asGIF && (c.hasID || c.hasTag) {
+		return "", ErrGIFWithID
+	}
 
 	// write the base
 	var b strings.Builder
 	b.WriteString(c.baseURL)
 
 	// add the ID/Tag if present
+	if c.asGIF {
+		b.WriteRune(caasPathSeparator)
+		b.WriteString(caasGIFEndpoint)
+	}
 	if c.hasID {
 		b.WriteRune(caasPathSeparator)
 		b.WriteString(c.catID)
 	}
 	if c.hasTag {
 		b.WriteRune(caasPathSeparator)
-		b.WriteString(c.tag)
+		b.WriteString(escapeTag(c.tag))
 	}
 	// add text overlay if present
 	if c.hasSays {
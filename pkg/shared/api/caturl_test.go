@@ -0,0 +1,301 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// setAvailableTags swaps the global tag list for the duration of a test
+func setAvailableTags(t *testing.T, tags CAASTags) {
+	t.Helper()
+	old := AvailableTags()
+	SetAvailableTags(tags)
+	t.Cleanup(func() { SetAvailableTags(old) })
+}
+
+// TestCatURL_WithTags tests tag-filtered URLs
+func TestCatURL_WithTags(t *testing.T) {
+	setAvailableTags(t, CAASTags{"cute", "orange", "sleepy cat"})
+
+	tests := []struct {
+		name     string
+		build    func() *CatURL
+		expected string
+	}{
+		{
+			name:     "single_tag",
+			build:    func() *CatURL { return NewCatURL().WithTag("cute") },
+			expected: "https://cataas.com/cat/cute",
+		},
+		{
+			name:     "multiple_tags",
+			build:    func() *CatURL { return NewCatURL().WithTags("cute", "orange") },
+			expected: "https://cataas.com/cat/cute,orange",
+		},
+		{
+			name:     "escaped_tag",
+			build:    func() *CatURL { return NewCatURL().WithTag("sleepy cat") },
+			expected: "https://cataas.com/cat/sleepy%20cat",
+		},
+		{
+			name:     "blank_tags_ignored",
+			build:    func() *CatURL { return NewCatURL().WithTags(" ", "") },
+			expected: "https://cataas.com/cat",
+		},
+		{
+			name:     "tag_kept_as_json",
+			build:    func() *CatURL { return NewCatURL().WithTag("cute").AsJSON() },
+			expected: "https://cataas.com/cat/cute?&json=true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.build().Generate()
+			testutil.AssertNoError(t, err, "Generate should succeed")
+			testutil.AssertEqual(t, tt.expected, got, "generated URL")
+		})
+	}
+}
+
+// TestCatURL_InvalidTag tests validation against the fetched tag list
+func TestCatURL_InvalidTag(t *testing.T) {
+	t.Run("unknown_tag", func(t *testing.T) {
+		setAvailableTags(t, CAASTags{"cute"})
+		_, err := NewCatURL().WithTags("cute", "purple").Generate()
+		testutil.AssertTrue(t, errors.Is(err, ErrInvalidTag), "unknown tag should be rejected")
+	})
+
+	t.Run("tags_not_loaded", func(t *testing.T) {
+		setAvailableTags(t, CAASTags{})
+		got, err := NewCatURL().WithTag("purple").Generate()
+		testutil.AssertNoError(t, err, "any tag is accepted before tags are loaded")
+		testutil.AssertEqual(t, "https://cataas.com/cat/purple", got, "generated URL")
+	})
+
+	t.Run("tags_loaded_while_generating", func(t *testing.T) {
+		setAvailableTags(t, CAASTags{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range 100 {
+				SetAvailableTags(CAASTags{"cute"})
+			}
+		}()
+		for range 100 {
+			NewCatURL().WithTag("cute").Generate()
+		}
+		<-done
+	})
+
+	t.Run("callers_get_a_copy", func(t *testing.T) {
+		setAvailableTags(t, CAASTags{"cute"})
+		AvailableTags()[0] = "changed"
+		testutil.AssertEqual(t, CAASTags{"cute"}, AvailableTags(), "list should not be modified by callers")
+	})
+
+	t.Run("id_and_tag", func(t *testing.T) {
+		_, err := NewCatURL().WithID("abc").WithTag("cute").AsJSON().Generate()
+		testutil.AssertTrue(t, errors.Is(err, ErrIDAndTag), "ID and tag should be rejected")
+	})
+}
+
+// TestRequestRandomCat_WithTags tests that tags reach the metadata request path
+func TestRequestRandomCat_WithTags(t *testing.T) {
+	setAvailableTags(t, CAASTags{})
+
+	var metaPath string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(testutil.ValidPNGBytes())
+			return
+		}
+		metaPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+	}))
+	defer mirror.Close()
+
+	_, _, err := RequestRandomCat(5*time.Second, WithBaseURL(mirror.URL), WithTags("cute", "orange"))
+	testutil.AssertNoError(t, err, "RequestRandomCat should succeed")
+	testutil.AssertEqual(t, "/cat/cute,orange", metaPath, "metadata path")
+}
+
+// TestCatURL_WithSays tests text overlay URLs and their font params
+func TestCatURL_WithSays(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func() *CatURL
+		expected string
+	}{
+		{
+			name:     "text_is_path_escaped",
+			build:    func() *CatURL { return NewCatURL().WithSays("hello world!") },
+			expected: "https://cataas.com/cat/says/hello%20world%21",
+		},
+		{
+			name:     "font_size_and_color",
+			build:    func() *CatURL { return NewCatURL().WithSays("hi").WithFontSize(40).WithFontColor("#ff79c6") },
+			expected: "https://cataas.com/cat/says/hi?fontSize=40&fontColor=%23ff79c6",
+		},
+		{
+			name:     "invalid_color_ignored",
+			build:    func() *CatURL { return NewCatURL().WithSays("hi").WithFontColor("pink") },
+			expected: "https://cataas.com/cat/says/hi",
+		},
+		{
+			name:     "font_params_need_text",
+			build:    func() *CatURL { return NewCatURL().WithFontSize(40) },
+			expected: "https://cataas.com/cat",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.build().Generate()
+			testutil.AssertNoError(t, err, "Generate should succeed")
+			testutil.AssertEqual(t, tt.expected, got, "generated URL")
+		})
+	}
+
+	t.Run("empty_text", func(t *testing.T) {
+		_, err := NewCatURL().WithSays("").Generate()
+		testutil.AssertTrue(t, errors.Is(err, ErrSaysNoText), "empty text should be rejected")
+	})
+}
+
+// TestRequestCatSaying tests the text overlay request
+func TestRequestCatSaying(t *testing.T) {
+	var metaURI string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(testutil.ValidPNGBytes())
+			return
+		}
+		metaURI = r.URL.RequestURI()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+	}))
+	defer mirror.Close()
+
+	t.Run("with_font", func(t *testing.T) {
+		img, _, err := RequestCatSaying("meow now", WithBaseURL(mirror.URL), WithFontSize(30), WithFontColor("#fff"), WithTimeout(5*time.Second))
+		testutil.AssertNoError(t, err, "RequestCatSaying should succeed")
+		testutil.AssertNotNil(t, img, "image")
+		testutil.AssertEqual(t, "/cat/says/meow%20now?fontSize=30&fontColor=%23fff&json=true", metaURI, "metadata request")
+	})
+
+	t.Run("empty_text", func(t *testing.T) {
+		_, _, err := RequestCatSaying("", WithBaseURL(mirror.URL))
+		testutil.AssertTrue(t, errors.Is(err, ErrSaysNoText), "empty text should be rejected")
+	})
+}
+
+// TestCatURL_AsGIF tests the animated cat endpoint
+func TestCatURL_AsGIF(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func() *CatURL
+		expected string
+	}{
+		{
+			name:     "gif",
+			build:    func() *CatURL { return NewCatURL().AsGIF() },
+			expected: "https://cataas.com/cat/gif",
+		},
+		{
+			name:     "says_and_json",
+			build:    func() *CatURL { return NewCatURL().AsGIF().WithSays("hi there").WithFontSize(20).AsJSON() },
+			expected: "https://cataas.com/cat/gif/says/hi%20there?fontSize=20&json=true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.build().Generate()
+			testutil.AssertNoError(t, err, "Generate should succeed")
+			testutil.AssertEqual(t, tt.expected, got, "generated URL")
+		})
+	}
+
+	t.Run("with_id", func(t *testing.T) {
+		_, err := NewCatURL().WithID("abc").AsGIF().Generate()
+		testutil.AssertTrue(t, errors.Is(err, ErrGIFWithID), "ID should be rejected")
+	})
+	t.Run("with_tag", func(t *testing.T) {
+		_, err := NewCatURL().AsGIF().WithTag("cute").Generate()
+		testutil.AssertTrue(t, errors.Is(err, ErrGIFWithID), "tag should be rejected")
+	})
+}
+
+// TestRequestRandomGIF tests the GIF request decodes the first frame
+func TestRequestRandomGIF(t *testing.T) {
+	gifBytes, err := testutil.CreateTestImageBytes(4, 3, "gif")
+	testutil.AssertNoError(t, err, "encoding GIF")
+
+	var metaURI string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			w.Header().Set("Content-Type", "image/gif")
+			w.Write(gifBytes)
+			return
+		}
+		metaURI = r.URL.RequestURI()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+	}))
+	defer mirror.Close()
+
+	img, _, err := RequestRandomGIF(5*time.Second, WithBaseURL(mirror.URL))
+	testutil.AssertNoError(t, err, "RequestRandomGIF should succeed")
+	testutil.AssertImageDimensions(t, img, 4, 3)
+	testutil.AssertEqual(t, "/cat/gif?&json=true", metaURI, "metadata request")
+
+	_, _, err = RequestRandomGIF(5*time.Second, WithBaseURL(mirror.URL), WithTags("cute"))
+	testutil.AssertTrue(t, errors.Is(err, ErrGIFWithID), "tags should be rejected")
+}
+
+// TestCatURL_Size tests the width, height, and type params
+func TestCatURL_Size(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func() *CatURL
+		expected string
+	}{
+		{
+			name:     "width_and_height",
+			build:    func() *CatURL { return NewCatURL().WithWidth(400).WithHeight(500) },
+			expected: "https://cataas.com/cat?width=400&height=500",
+		},
+		{
+			name:     "non_positive_ignored",
+			build:    func() *CatURL { return NewCatURL().WithWidth(0).WithHeight(-5) },
+			expected: "https://cataas.com/cat",
+		},
+		{
+			name:     "type",
+			build:    func() *CatURL { return NewCatURL().WithCAASImageType(CAASImageTypeSmall) },
+			expected: "https://cataas.com/cat?type=small",
+		},
+		{
+			name:     "unknown_type_ignored",
+			build:    func() *CatURL { return NewCatURL().WithCAASImageType(CAASImageType(99)) },
+			expected: "https://cataas.com/cat",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.build().Generate()
+			testutil.AssertNoError(t, err, "Generate should succeed")
+			testutil.AssertEqual(t, tt.expected, got, "generated URL")
+		})
+	}
+}
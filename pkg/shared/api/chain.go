@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrNoProvider      = errors.New("no provider in chain")
+	ErrUnsupportedTags = errors.New("provider doesn't list the tags")
+)
+
+// FallbackChain is a CatProvider that asks its providers in order, falling
+// back to the next when one fails or times out, e.g. cataas then
+// thecatapi.com. FetchResult.Source tells which one served the cat.
+// WithTimeout bounds each attempt rather than the whole chain. Tags mean
+// something different to each provider, cataas tags aren't thecatapi.com
+// breed IDs, so providers that don't list all the tags asked for are
+// skipped. Other options a provider doesn't support are ignored.
+type FallbackChain struct {
+	providers []CatProvider
+}
+
+var _ CatProvider = (*FallbackChain)(nil)
+
+// NewFallbackChain returns a chain trying providers in the given order
+func NewFallbackChain(providers ...CatProvider) *FallbackChain {
+	return &FallbackChain{providers: providers}
+}
+
+// FetchRandom fetches a random cat from the first provider that has one
+func (c *FallbackChain) FetchRandom(ctx context.Context, opts ...Option) (*FetchResult, error) {
+	tags := newOptions(opts).tags
+	return firstOf(ctx, c.providers, opts, func(p CatProvider) (*FetchResult, error) {
+		if err := listsTags(ctx, p, tags); err != nil {
+			return nil, err
+		}
+		return p.FetchRandom(ctx, opts...)
+	})
+}
+
+// FetchByID fetches the cat from the first provider that knows the ID, IDs
+// are usually only known to the provider that served the cat
+func (c *FallbackChain) FetchByID(ctx context.Context, id string, opts ...Option) (*FetchResult, error) {
+	if id == "" {
+		return nil, ErrNoID
+	}
+	return firstOf(ctx, c.providers, opts, func(p CatProvider) (*FetchResult, error) {
+		return p.FetchByID(ctx, id, opts...)
+	})
+}
+
+// ListTags returns the tags of the first provider that lists them
+func (c *FallbackChain) ListTags(ctx context.Context, opts ...Option) (CAASTags, error) {
+	return firstOf(ctx, c.providers, opts, func(p CatProvider) (CAASTags, error) {
+		return p.ListTags(ctx, opts...)
+	})
+}
+
+// listsTags checks p lists all of tags, with ListTags
+func listsTags(ctx context.Context, p CatProvider, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	known, err := p.ListTags(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if !slices.Contains(known, tag) {
+			return fmt.Errorf("%w: %s", ErrUnsupportedTags, tag)
+		}
+	}
+	return nil
+}
+
+// firstOf calls fetch with each provider until one succeeds, returning all
+// the errors joined if none does. It stops early once ctx is done.
+func firstOf[T any](ctx context.Context, providers []CatProvider, opts []Option, fetch func(CatProvider) (T, error)) (T, error) {
+	var zero T
+	if len(providers) == 0 {
+		return zero, ErrNoProvider
+	}
+
+	var errs []error
+	for i, p := range providers {
+		res, err := fetch(p)
+		if err == nil {
+			return res, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+		if i < len(providers)-1 {
+			newOptions(opts).log().Info("Provider failed, falling back", "provider", i, "err", err)
+		}
+	}
+	return zero, errors.Join(errs...)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// stubProvider returns the same result or error every time
+type stubProvider struct {
+	res   *FetchResult
+	tags  CAASTags
+	err   error
+	calls int
+}
+
+func (p *stubProvider) FetchRandom(ctx context.Context, opts ...Option) (*FetchResult, error) {
+	p.calls++
+	return p.res, p.err
+}
+
+func (p *stubProvider) FetchByID(ctx context.Context, id string, opts ...Option) (*FetchResult, error) {
+	p.calls++
+	return p.res, p.err
+}
+
+func (p *stubProvider) ListTags(ctx context.Context, opts ...Option) (CAASTags, error) {
+	p.calls++
+	return p.tags, p.err
+}
+
+// TestFallbackChain tests falling back in order and collecting errors
+func TestFallbackChain(t *testing.T) {
+	errDown := errors.New("down")
+	errGone := errors.New("gone")
+
+	t.Run("first_serves", func(t *testing.T) {
+		first := &stubProvider{res: &FetchResult{Source: "first"}}
+		second := &stubProvider{res: &FetchResult{Source: "second"}}
+
+		res, err := NewFallbackChain(first, second).FetchRandom(context.Background())
+		testutil.AssertNoError(t, err, "FetchRandom should succeed")
+		testutil.AssertEqual(t, "first", res.Source, "source")
+		testutil.AssertEqual(t, 0, second.calls, "second not asked")
+	})
+
+	t.Run("falls_back", func(t *testing.T) {
+		first := &stubProvider{err: errDown}
+		second := &stubProvider{res: &FetchResult{Source: "second"}}
+
+		res, err := NewFallbackChain(first, second).FetchByID(context.Background(), "abc")
+		testutil.AssertNoError(t, err, "FetchByID should succeed")
+		testutil.AssertEqual(t, "second", res.Source, "source")
+	})
+
+	t.Run("all_fail", func(t *testing.T) {
+		_, err := NewFallbackChain(&stubProvider{err: errDown}, &stubProvider{err: errGone}).FetchRandom(context.Background())
+		testutil.AssertTrue(t, errors.Is(err, errDown), "first error kept")
+		testutil.AssertTrue(t, errors.Is(err, errGone), "second error kept")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		second := &stubProvider{res: &FetchResult{}}
+
+		_, err := NewFallbackChain(&stubProvider{err: context.Canceled}, second).FetchRandom(ctx)
+		testutil.AssertTrue(t, errors.Is(err, context.Canceled), "should be canceled")
+		testutil.AssertEqual(t, 0, second.calls, "no fallback once canceled")
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		tags, err := NewFallbackChain(&stubProvider{err: errDown}, &stubProvider{tags: CAASTags{"beng"}}).ListTags(context.Background())
+		testutil.AssertNoError(t, err, "ListTags should succeed")
+		testutil.AssertEqual(t, 1, len(tags), "tags of the second provider")
+	})
+
+	t.Run("skips_unknown_tags", func(t *testing.T) {
+		first := &stubProvider{tags: CAASTags{"beng"}, res: &FetchResult{Source: "first"}}
+		second := &stubProvider{tags: CAASTags{"orange", "cute"}, res: &FetchResult{Source: "second"}}
+
+		res, err := NewFallbackChain(first, second).FetchRandom(context.Background(), WithTags("orange"))
+		testutil.AssertNoError(t, err, "FetchRandom should succeed")
+		testutil.AssertEqual(t, "second", res.Source, "served by the provider listing the tag")
+		testutil.AssertEqual(t, 1, first.calls, "first only asked for its tags")
+
+		_, err = NewFallbackChain(first, second).FetchRandom(context.Background(), WithTags("orange", "beng"))
+		testutil.AssertTrue(t, errors.Is(err, ErrUnsupportedTags), "should be ErrUnsupportedTags")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := NewFallbackChain().FetchRandom(context.Background())
+		testutil.AssertTrue(t, errors.Is(err, ErrNoProvider), "should be ErrNoProvider")
+	})
+
+	t.Run("no_id", func(t *testing.T) {
+		first := &stubProvider{}
+		_, err := NewFallbackChain(first).FetchByID(context.Background(), "")
+		testutil.AssertTrue(t, errors.Is(err, ErrNoID), "should be ErrNoID")
+		testutil.AssertEqual(t, 0, first.calls, "no provider asked")
+	})
+}
+
+// TestFallbackChain_Providers tests falling back from cataas to thecatapi.com
+// and the source each sets
+func TestFallbackChain_Providers(t *testing.T) {
+	down, _ := flakyServer(t, 100, http.StatusServiceUnavailable)
+	cataas := NewClient(nil, WithBaseURL(down.URL), WithoutRetries())
+	server, _ := theCatAPIServer(t, false)
+	theCatAPI := NewTheCatAPI("", nil, WithBaseURL(server.URL))
+
+	res, err := NewFallbackChain(cataas, theCatAPI).FetchRandom(context.Background())
+	testutil.AssertNoError(t, err, "FetchRandom should succeed")
+	testutil.AssertEqual(t, SourceTheCatAPI, res.Source, "served by thecatapi.com")
+
+	res, err = NewFallbackChain(NewClient(nil, WithBaseURL(catServer(t).URL)), theCatAPI).FetchRandom(context.Background())
+	testutil.AssertNoError(t, err, "FetchRandom should succeed")
+	testutil.AssertEqual(t, SourceCataas, res.Source, "served by cataas")
+}
+
+// TestFallbackChain_TaggedFallback tests cataas tags aren't sent to
+// thecatapi.com as breed IDs when cataas is down
+func TestFallbackChain_TaggedFallback(t *testing.T) {
+	down, _ := flakyServer(t, 100, http.StatusServiceUnavailable)
+	cataas := NewClient(nil, WithBaseURL(down.URL), WithoutRetries())
+	server, last := theCatAPIServer(t, false)
+	theCatAPI := NewTheCatAPI("", nil, WithBaseURL(server.URL))
+
+	_, err := NewFallbackChain(cataas, theCatAPI).FetchRandom(context.Background(), WithTags("orange"))
+	testutil.AssertTrue(t, errors.Is(err, ErrUnsupportedTags), "should be ErrUnsupportedTags, got "+errString(err))
+	testutil.AssertEqual(t, "/v1/breeds", last.Load().(*http.Request).URL.Path, "only the breeds were listed")
+
+	res, err := NewFallbackChain(cataas, theCatAPI).FetchRandom(context.Background(), WithTags("beng"))
+	testutil.AssertNoError(t, err, "FetchRandom should succeed")
+	testutil.AssertEqual(t, SourceTheCatAPI, res.Source, "breed ID served by thecatapi.com")
+}
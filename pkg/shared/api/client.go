@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"image"
+	"net/http"
+	"slices"
+)
+
+// Client fetches cats with its own *http.Client, so callers and tests can
+// supply a transport without touching http.DefaultTransport. Options given
+// to NewClient apply to every call, before the options of the call itself.
+type Client struct {
+	httpClient *http.Client
+	opts       []Option
+	tags       *tagCache
+	flights    *flightGroup
+}
+
+// defaultClient backs the package level functions. Its transport is left
+// nil so http.DefaultTransport is looked up on every request.
+var defaultClient = NewClient(nil)
+
+// NewClient wraps httpClient, or a client with DefaultTimeout when nil
+func NewClient(httpClient *http.Client, opts ...Option) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{
+		httpClient: httpClient,
+		opts:       opts,
+		tags:       &tagCache{},
+		flights:    &flightGroup{},
+	}
+}
+
+// RandomCat fetches the metadata and image of a random cat
+func (c *Client) RandomCat(opts ...Option) (image.Image, *CatMetadata, error) {
+	return unpack(c.FetchRandom(context.Background(), opts...))
+}
+
+// CatSaying fetches a random cat with text drawn over it
+func (c *Client) CatSaying(text string, opts ...Option) (image.Image, *CatMetadata, error) {
+	o := c.options(opts)
+	o.says = text
+	o.hasSays = true
+	return unpack(c.fetchCat(context.Background(), o))
+}
+
+// FetchRandom fetches a random cat, stopping when ctx is done
+func (c *Client) FetchRandom(ctx context.Context, opts ...Option) (*FetchResult, error) {
+	return c.fetchCat(ctx, c.options(opts))
+}
+
+// FetchByID fetches the cat with the given ID
+func (c *Client) FetchByID(ctx context.Context, id string, opts ...Option) (*FetchResult, error) {
+	if id == "" {
+		return nil, ErrNoID
+	}
+	o := c.options(opts)
+	o.catID = id
+	return c.fetchCat(ctx, o)
+}
+
+func (c *Client) options(opts []Option) *options {
+	return newOptions(append(slices.Clone(c.opts), opts...))
+}
+
+// httpClientFor shares the wrapped client's transport, replacing its timeout
+// when WithTimeout was given
+func (c *Client) httpClientFor(o *options) *http.Client {
+	if !o.hasTimeout {
+		return c.httpClient
+	}
+	hc := *c.httpClient
+	hc.Timeout = o.timeout
+	return &hc
+}
+
+// imageClientFor is httpClientFor for image downloads, which checks every
+// redirect like the image URL. A nil transport is replaced by a guarded one,
+// see guardDial.
+func (c *Client) imageClientFor(o *options) *http.Client {
+	hc := *c.httpClientFor(o)
+	if hc.Transport == nil {
+		hc.Transport = defaultTransport()
+	}
+	hc.CheckRedirect = o.checkRedirect(hc.CheckRedirect)
+	return &hc
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// countingTransport counts round trips before handing them to the next transport
+type countingTransport struct {
+	count atomic.Int32
+	next  http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.count.Add(1)
+	return t.next.RoundTrip(req)
+}
+
+// catServer serves metadata on /cat and a PNG on /image
+func catServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(testutil.ValidPNGBytes())
+		case "/api/tags":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`["cute","orange"]`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestNewClient tests the default client
+func TestNewClient(t *testing.T) {
+	c := NewClient(nil)
+	testutil.AssertNotNil(t, c.httpClient, "http client")
+	testutil.AssertEqual(t, DefaultTimeout, c.httpClient.Timeout, "default timeout")
+}
+
+// TestClient_HTTPClientFor tests timeout overrides keep the transport
+func TestClient_HTTPClientFor(t *testing.T) {
+	transport := &countingTransport{next: http.DefaultTransport}
+	hc := &http.Client{Transport: transport, Timeout: time.Minute}
+	c := NewClient(hc)
+
+	same := c.httpClientFor(newOptions(nil))
+	testutil.AssertTrue(t, same == hc, "no timeout option should reuse the client")
+
+	overridden := c.httpClientFor(newOptions([]Option{WithTimeout(time.Second)}))
+	testutil.AssertEqual(t, time.Second, overridden.Timeout, "timeout override")
+	testutil.AssertTrue(t, overridden.Transport == http.RoundTripper(transport), "transport should be shared")
+	testutil.AssertEqual(t, time.Minute, hc.Timeout, "wrapped client should be untouched")
+}
+
+// TestClient_RandomCat tests fetching through an injected transport, in parallel
+func TestClient_RandomCat(t *testing.T) {
+	for _, name := range []string{"first", "second", "third"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := catServer(t)
+			transport := &countingTransport{next: http.DefaultTransport}
+			c := NewClient(&http.Client{Transport: transport}, WithBaseURL(server.URL))
+
+			img, meta, err := c.RandomCat(WithTimeout(5 * time.Second))
+			testutil.AssertNoError(t, err, "RandomCat should succeed")
+			testutil.AssertNotNil(t, img, "image")
+			testutil.AssertNotNil(t, meta, "metadata")
+			testutil.AssertEqual(t, int32(2), transport.count.Load(), "requests through the transport")
+		})
+	}
+}
+
+// TestClient_CatSaying tests the text overlay through a client
+func TestClient_CatSaying(t *testing.T) {
+	server := catServer(t)
+	c := NewClient(nil, WithBaseURL(server.URL))
+
+	img, _, err := c.CatSaying("hello")
+	testutil.AssertNoError(t, err, "CatSaying should succeed")
+	testutil.AssertNotNil(t, img, "image")
+}
+
+// TestClient_Tags tests fetching the tag list through a client
+func TestClient_Tags(t *testing.T) {
+	server := catServer(t)
+	c := NewClient(nil, WithBaseURL(server.URL))
+
+	tags, err := c.Tags(context.Background())
+	testutil.AssertNoError(t, err, "Tags should succeed")
+	testutil.AssertEqual(t, 2, len(tags), "tag count")
+	testutil.AssertEqual(t, "cute", tags[0], "first tag")
+}
+
+// TestClient_MaxBodySize tests oversized image downloads are cut off, with
+// and without a Content-Length
+func TestClient_MaxBodySize(t *testing.T) {
+	png := testutil.ValidPNGBytes()
+
+	tests := []struct {
+		name    string
+		chunked bool
+		limit   int64
+		wantErr bool
+	}{
+		{"fits", false, int64(len(png)), false},
+		{"declared_too_large", false, int64(len(png)) - 1, true},
+		{"streamed_too_large", true, int64(len(png)) - 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/image" {
+					w.Header().Set("Content-Type", "application/json")
+					w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+					return
+				}
+				w.Header().Set("Content-Type", "image/png")
+				if !tt.chunked {
+					w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+				}
+				w.Write(png)
+				// flushing before the handler returns keeps the length unknown
+				w.(http.Flusher).Flush()
+			}))
+			defer server.Close()
+
+			c := NewClient(nil, WithBaseURL(server.URL), WithMaxBodySize(tt.limit))
+			img, meta, err := c.RandomCat()
+			if tt.wantErr {
+				testutil.AssertTrue(t, errors.Is(err, ErrImageTooLarge), "should be ErrImageTooLarge")
+				return
+			}
+			testutil.AssertNoError(t, err, "RandomCat should succeed")
+			testutil.AssertNotNil(t, img, "image")
+			testutil.AssertEqual(t, len(png), meta.GetSize(), "size")
+		})
+	}
+}
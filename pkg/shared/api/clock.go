@@ -0,0 +1,79 @@
+package api
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Clock is a source of time, see WithClock
+type Clock interface {
+	Now() time.Time
+	// After sends the time on the returned channel once d has passed
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock of time.Now and time.After
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock takes the time from clock instead of the system clock, for the
+// tag list TTL, Retry-After dates, retry and prefetch delays, and request
+// timings. Tests use it to expire cached tags and to wait without sleeping.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithRandSource draws the jitter of retry and prefetch delays from src
+// instead of the global source, so the delays repeat from run to run. src
+// is locked, the option may be shared by concurrent fetches.
+func WithRandSource(src rand.Source) Option {
+	r := &lockedRand{r: rand.New(src)}
+	return func(o *options) {
+		o.rand = r
+	}
+}
+
+// lockedRand guards a rand.Rand, which is not safe for concurrent use
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) int64N(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int64N(n)
+}
+
+// now returns the time from the clock given by WithClock, or time.Now
+func (o *options) now() time.Time {
+	if o.clock != nil {
+		return o.clock.Now()
+	}
+	return time.Now()
+}
+
+// after waits for d on the clock given by WithClock, or with time.After
+func (o *options) after(d time.Duration) <-chan time.Time {
+	if o.clock != nil {
+		return o.clock.After(d)
+	}
+	return time.After(d)
+}
+
+// jitter returns a random duration in [0, n), from the source given by
+// WithRandSource or the global one
+func (o *options) jitter(n time.Duration) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	if o.rand != nil {
+		return time.Duration(o.rand.int64N(int64(n)))
+	}
+	return rand.N(n)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// fakeClock is a time source tests move forward by hand. Waiting on it
+// moves it forward by the wait right away.
+type fakeClock struct {
+	mu    sync.Mutex
+	t     time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.t
+	return ch
+}
+
+func (c *fakeClock) waited() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.waits)
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// TestWithClock tests that the tag list expires by the given clock
+func TestWithClock(t *testing.T) {
+	ctx := context.Background()
+	server, calls := tagServer(t, nil)
+	clock := &fakeClock{t: time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)}
+	c := NewClient(nil, WithBaseURL(server.URL), WithTagsTTL(time.Hour), WithClock(clock))
+
+	c.ListTags(ctx)
+	clock.advance(59 * time.Minute)
+	c.ListTags(ctx)
+	testutil.AssertEqual(t, int32(1), calls.Load(), "list should be cached within the TTL")
+
+	clock.advance(time.Minute)
+	c.ListTags(ctx)
+	testutil.AssertEqual(t, int32(2), calls.Load(), "list should be refetched once the clock passes the TTL")
+}
+
+// TestWithClock_Backoff tests retries wait on the given clock
+func TestWithClock_Backoff(t *testing.T) {
+	server, _ := flakyServer(t, 2, http.StatusServiceUnavailable)
+	clock := &fakeClock{t: time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)}
+	policy := DefaultRetryPolicy
+	policy.BaseDelay, policy.MaxDelay = time.Hour, time.Hour
+
+	start := time.Now()
+	_, _, err := RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithRetryPolicy(policy), WithClock(clock))
+	testutil.AssertNoError(t, err, "should succeed on the third attempt")
+	testutil.AssertEqual(t, 2, len(clock.waited()), "waited before each retry")
+	testutil.AssertTrue(t, time.Since(start) < time.Minute, "hour long backoff shouldn't sleep")
+}
+
+// TestWithRandSource tests that seeded sources give repeatable backoff
+func TestWithRandSource(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}
+	delays := func(seed uint64) []time.Duration {
+		o := newOptions([]Option{WithRandSource(rand.NewPCG(seed, seed))})
+		var d []time.Duration
+		for n := 1; n <= 5; n++ {
+			d = append(d, p.backoff(n, o))
+		}
+		return d
+	}
+
+	first := delays(1)
+	testutil.AssertEqual(t, first, delays(1), "same seed should give the same delays")
+	testutil.AssertTrue(t, first[0] >= 500*time.Millisecond && first[0] <= time.Second, "jitter within bounds")
+	testutil.AssertEqual(t, time.Duration(0), newOptions(nil).jitter(0), "no jitter without a range")
+}
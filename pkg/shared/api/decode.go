@@ -0,0 +1,110 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+)
+
+var (
+	ErrImageTooLarge = errors.New("image too large")
+)
+
+// DecodeLimits bounds the dimensions of images we are willing to decode.
+// A zero value for any field disables that check.
+type DecodeLimits struct {
+	MaxWidth  int // maximum width in pixels
+	MaxHeight int // maximum height in pixels
+	MaxPixels int // maximum width*height
+}
+
+// defaultDecodeLimits apply unless WithDecodeLimits is given. The pixel cap
+// keeps a fully decoded RGBA image around 256MB, well above any real cat
+// photo but far below a decompression bomb.
+var defaultDecodeLimits = DecodeLimits{
+	MaxWidth:  16384,
+	MaxHeight: 16384,
+	MaxPixels: 64 * 1024 * 1024,
+}
+
+// DefaultDecodeLimits returns the limits used unless WithDecodeLimits is given
+func DefaultDecodeLimits() DecodeLimits {
+	return defaultDecodeLimits
+}
+
+// WithDecodeLimits replaces the limits images are decoded under, a zero
+// DecodeLimits decodes anything
+func WithDecodeLimits(limits DecodeLimits) Option {
+	return func(o *options) {
+		o.decodeLimits = limits
+	}
+}
+
+// Check returns ErrImageTooLarge if the given dimensions exceed the limits
+func (l DecodeLimits) Check(width, height int) error {
+	if l.MaxWidth > 0 && width > l.MaxWidth {
+		return fmt.Errorf("%w: width %d > %d", ErrImageTooLarge, width, l.MaxWidth)
+	}
+	if l.MaxHeight > 0 && height > l.MaxHeight {
+		return fmt.Errorf("%w: height %d > %d", ErrImageTooLarge, height, l.MaxHeight)
+	}
+	// compare in int64 so huge headers can't overflow on 32-bit platforms
+	if l.MaxPixels > 0 && int64(width)*int64(height) > int64(l.MaxPixels) {
+		return fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrImageTooLarge, width, height, l.MaxPixels)
+	}
+	return nil
+}
+
+type DecodeStrategy int
+
+const (
+	DecodeStrategyFull   DecodeStrategy = iota // decode as is
+	DecodeStrategyReject                       // don't decode at all
+)
+
+// DecodePlan describes an image from its header alone and how it will be decoded
+type DecodePlan struct {
+	Format   string
+	Width    int
+	Height   int
+	Strategy DecodeStrategy
+	Err      error // why the image was rejected, if it was
+}
+
+// PlanDecode reads only the image header and picks a decode strategy
+func PlanDecode(data []byte, limits DecodeLimits) (DecodePlan, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return DecodePlan{}, err
+	}
+
+	plan := DecodePlan{
+		Format:   format,
+		Width:    cfg.Width,
+		Height:   cfg.Height,
+		Strategy: DecodeStrategyFull,
+	}
+
+	if err := limits.Check(cfg.Width, cfg.Height); err != nil {
+		plan.Strategy = DecodeStrategyReject
+		plan.Err = err
+		return plan, nil
+	}
+
+	return plan, nil
+}
+
+// DecodeImage plans the decode from the header and only touches pixel data if
+// the image is within limits
+func DecodeImage(data []byte, limits DecodeLimits) (image.Image, string, error) {
+	plan, err := PlanDecode(data, limits)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if plan.Strategy == DecodeStrategyReject {
+		return nil, plan.Format, plan.Err
+	}
+	return image.Decode(bytes.NewReader(data))
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestDecodeLimits_Check tests dimension checks against limits
+func TestDecodeLimits_Check(t *testing.T) {
+	limits := DecodeLimits{MaxWidth: 100, MaxHeight: 50, MaxPixels: 4000}
+
+	tests := []struct {
+		name    string
+		width   int
+		height  int
+		wantErr bool
+	}{
+		{name: "within_limits", width: 80, height: 50, wantErr: false},
+		{name: "too_wide", width: 101, height: 10, wantErr: true},
+		{name: "too_tall", width: 10, height: 51, wantErr: true},
+		{name: "too_many_pixels", width: 100, height: 41, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.Check(tt.width, tt.height)
+			if tt.wantErr {
+				testutil.AssertTrue(t, errors.Is(err, ErrImageTooLarge), "should be ErrImageTooLarge")
+			} else {
+				testutil.AssertNoError(t, err, "should be within limits")
+			}
+		})
+	}
+
+	t.Run("zero_limits_disable_checks", func(t *testing.T) {
+		testutil.AssertNoError(t, DecodeLimits{}.Check(1<<20, 1<<20), "zero limits should allow anything")
+	})
+}
+
+// TestDecodeImage tests decoding with header-first limit enforcement
+func TestDecodeImage(t *testing.T) {
+	data, err := testutil.CreateTestImageBytes(20, 10, "png")
+	testutil.AssertNoError(t, err, "create test image")
+
+	t.Run("decodes_within_limits", func(t *testing.T) {
+		img, format, err := DecodeImage(data, DefaultDecodeLimits())
+		testutil.AssertNoError(t, err, "should decode")
+		testutil.AssertEqual(t, "png", format, "format")
+		testutil.AssertImageDimensions(t, img, 20, 10)
+	})
+
+	t.Run("rejects_over_limits", func(t *testing.T) {
+		img, _, err := DecodeImage(data, DecodeLimits{MaxWidth: 10})
+		testutil.AssertTrue(t, errors.Is(err, ErrImageTooLarge), "should be ErrImageTooLarge")
+		testutil.AssertNil(t, img, "image should be nil")
+	})
+
+	t.Run("rejects_bomb_header", func(t *testing.T) {
+		// Encode a tiny image, then patch the IHDR to claim 30000x30000
+		var buf bytes.Buffer
+		testutil.AssertNoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))), "encode")
+		bomb := buf.Bytes()
+		// IHDR width/height live at bytes 16-23
+		copy(bomb[16:24], []byte{0x00, 0x00, 0x75, 0x30, 0x00, 0x00, 0x75, 0x30})
+		// fix up the chunk CRC over the type and data so the header still parses
+		binary.BigEndian.PutUint32(bomb[29:33], crc32.ChecksumIEEE(bomb[12:29]))
+
+		_, _, err := DecodeImage(bomb, DefaultDecodeLimits())
+		testutil.AssertTrue(t, errors.Is(err, ErrImageTooLarge), "should reject before decoding pixels")
+	})
+
+	t.Run("invalid_data", func(t *testing.T) {
+		_, _, err := DecodeImage(testutil.CorruptedImageBytes(), DefaultDecodeLimits())
+		testutil.AssertError(t, err, "corrupted data should fail")
+	})
+}
+
+// TestPlanDecode tests strategy selection from the image header
+func TestPlanDecode(t *testing.T) {
+	data, err := testutil.CreateTestImageBytes(200, 100, "png")
+	testutil.AssertNoError(t, err, "create test image")
+
+	tests := []struct {
+		name     string
+		limits   DecodeLimits
+		strategy DecodeStrategy
+	}{
+		{name: "full", limits: DecodeLimits{MaxWidth: 400, MaxHeight: 400}, strategy: DecodeStrategyFull},
+		{name: "reject", limits: DecodeLimits{MaxPixels: 100}, strategy: DecodeStrategyReject},
+		{name: "no_limits", limits: DecodeLimits{}, strategy: DecodeStrategyFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanDecode(data, tt.limits)
+			testutil.AssertNoError(t, err, "plan should succeed")
+			testutil.AssertEqual(t, "png", plan.Format, "format")
+			testutil.AssertEqual(t, 200, plan.Width, "width")
+			testutil.AssertEqual(t, 100, plan.Height, "height")
+			testutil.AssertEqual(t, tt.strategy, plan.Strategy, "strategy")
+		})
+	}
+
+	t.Run("invalid_header", func(t *testing.T) {
+		_, err := PlanDecode(testutil.PartialImageBytes(), DefaultDecodeLimits())
+		testutil.AssertError(t, err, "truncated header should fail")
+	})
+}
+
+// TestWithDecodeLimits tests fetches decode under the limits given
+func TestWithDecodeLimits(t *testing.T) {
+	data, err := testutil.CreateTestImageBytes(20, 10, "png")
+	testutil.AssertNoError(t, err, "create test image")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+	}))
+	defer server.Close()
+
+	_, _, err = RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithDecodeLimits(DecodeLimits{MaxWidth: 10}))
+	testutil.AssertTrue(t, errors.Is(err, ErrImageTooLarge), "should be rejected by the given limits")
+
+	img, _, err := RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithDecodeLimits(DecodeLimits{MaxWidth: 20}))
+	testutil.AssertNoError(t, err, "should decode")
+	testutil.AssertImageDimensions(t, img, 20, 10)
+
+	testutil.AssertEqual(t, defaultDecodeLimits, newOptions(nil).decodeLimits, "default limits")
+}
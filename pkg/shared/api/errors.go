@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Fetches wrap their failures in one of these, keeping the underlying error
+// in the chain, so callers can tell what went wrong with errors.Is. A
+// non-2xx response is a *StatusError instead, and an oversized image is
+// ErrImageTooLarge.
+var (
+	ErrTimeout  = errors.New("request timed out")
+	ErrNetwork  = errors.New("network error")
+	ErrMetadata = errors.New("invalid metadata")
+	ErrDecode   = errors.New("image could not be decoded")
+)
+
+// wrapError returns err wrapped in kind, so both are in the chain
+func wrapError(kind, err error) error {
+	return fmt.Errorf("%w: %w", kind, err)
+}
+
+// transportError classifies an error from sending a request or reading its
+// body. Cancellation is returned as is, since the caller asked for it.
+func transportError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return err
+	case isTimeout(err):
+		return wrapError(ErrTimeout, err)
+	default:
+		return wrapError(ErrNetwork, err)
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestRequestRandomCat_ErrorKinds tests each failure comes back wrapped in
+// its sentinel
+func TestRequestRandomCat_ErrorKinds(t *testing.T) {
+	serve := func(metadata string, img []byte, delay time.Duration) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			if r.URL.Path == "/image" {
+				w.Write(img)
+				return
+			}
+			w.Write([]byte(metadata))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	valid := testutil.ValidMetadataJSONWithURL("/image")
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		baseURL string
+		timeout time.Duration
+		want    error
+	}{
+		{"malformed_metadata", serve(testutil.MalformedMetadataJSON(), nil, 0).URL, time.Second, ErrMetadata},
+		{"empty_metadata", serve("", nil, 0).URL, time.Second, ErrMetadata},
+		{"no_image_url", serve(`{"id":"abc"}`, nil, 0).URL, time.Second, ErrNoImageURL},
+		{"corrupted_image", serve(valid, testutil.CorruptedImageBytes(), 0).URL, time.Second, ErrDecode},
+		{"timeout", serve(valid, nil, 200*time.Millisecond).URL, 50 * time.Millisecond, ErrTimeout},
+		{"connection_refused", closed.URL, time.Second, ErrNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := RequestRandomCat(tt.timeout, WithBaseURL(tt.baseURL), WithoutRetries())
+			testutil.AssertTrue(t, errors.Is(err, tt.want), "should be "+tt.want.Error()+", got "+errString(err))
+
+			var reqErr *RequestError
+			testutil.AssertTrue(t, errors.As(err, &reqErr), "request ID kept")
+		})
+	}
+}
+
+// TestRequestRandomCat_CanceledIsNotWrapped tests cancellation stays a
+// plain context error
+func TestRequestRandomCat_CanceledIsNotWrapped(t *testing.T) {
+	server := catServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewClient(nil, WithBaseURL(server.URL)).FetchRandom(ctx)
+	testutil.AssertTrue(t, errors.Is(err, context.Canceled), "should be canceled")
+	testutil.AssertTrue(t, !errors.Is(err, ErrNetwork), "not a network error")
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "nil"
+	}
+	return err.Error()
+}
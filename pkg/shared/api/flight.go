@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup coalesces concurrent downloads of the same image URL, so
+// button spam, the prefetcher and a slideshow asking for one cat at once
+// cause a single download whose result they all share
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a download in progress
+type flight struct {
+	done    chan struct{}
+	val     cachedImage
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do runs fetch for key unless a fetch for it is already running, and
+// waits for that one instead. joined reports whether another call's fetch
+// was shared. The fetch outlives the caller that started it and is only
+// canceled once every waiting caller's ctx is done.
+func (g *flightGroup) do(ctx context.Context, key string, fetch func(ctx context.Context) (cachedImage, error)) (val cachedImage, joined bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*flight{}
+	}
+	f, joined := g.calls[key]
+	if joined {
+		f.waiters++
+	} else {
+		// keep the values of ctx, such as a trace, but not its cancellation
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = f
+		go g.run(fctx, key, f, fetch)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.val, joined, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// nobody wants it, later callers start afresh
+			f.cancel()
+			g.forget(key, f)
+		}
+		g.mu.Unlock()
+		return cachedImage{}, joined, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fetch func(ctx context.Context) (cachedImage, error)) {
+	f.val, f.err = fetch(ctx)
+	f.cancel()
+
+	g.mu.Lock()
+	g.forget(key, f)
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// forget removes f unless a newer flight replaced it, g.mu must be held
+func (g *flightGroup) forget(key string, f *flight) {
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// waiters is the number of callers waiting on the flight for key
+func (g *flightGroup) waiters(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if f, ok := g.calls[key]; ok {
+		return f.waiters
+	}
+	return 0
+}
+
+// TestClient_CoalescesDownloads tests concurrent fetches of one image share
+// a download
+func TestClient_CoalescesDownloads(t *testing.T) {
+	release := make(chan struct{})
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			downloads.Add(1)
+			<-release
+			w.Write(testutil.ValidPNGBytes())
+			return
+		}
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+	}))
+	defer server.Close()
+
+	c := NewClient(nil, WithBaseURL(server.URL))
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([]*FetchResult, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = c.FetchRandom(context.Background())
+		}()
+	}
+
+	waitFor(t, func() bool { return c.flights.waiters(server.URL+"/image") == callers }, "all callers should wait on one download")
+	close(release)
+	wg.Wait()
+
+	testutil.AssertEqual(t, int32(1), downloads.Load(), "one download")
+	for i := range callers {
+		testutil.AssertNoError(t, errs[i], "fetch should succeed")
+		testutil.AssertTrue(t, results[i].Image == results[0].Image, "image shared")
+	}
+	testutil.AssertTrue(t, results[0].Metadata != results[1].Metadata, "metadata not shared")
+	testutil.AssertEqual(t, 0, c.flights.waiters(server.URL+"/image"), "flight forgotten")
+}
+
+// TestClient_JoinedDownloadLimits tests a fetch joining a download started
+// under looser limits still gets its own limits applied
+func TestClient_JoinedDownloadLimits(t *testing.T) {
+	data, err := testutil.CreateTestImageBytes(20, 10, "png")
+	testutil.AssertNoError(t, err, "create test image")
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			<-release
+			w.Write(data)
+			return
+		}
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+	}))
+	defer server.Close()
+
+	c := NewClient(nil, WithBaseURL(server.URL))
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"body_size", []Option{WithMaxBodySize(int64(len(data)) - 1)}},
+		{"decode_limits", []Option{WithDecodeLimits(DecodeLimits{MaxWidth: 10})}},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(tests)+1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, errs[0] = c.FetchRandom(context.Background())
+	}()
+	waitFor(t, func() bool { return c.flights.waiters(server.URL+"/image") == 1 }, "first download should start")
+	for i, tt := range tests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i+1] = c.FetchRandom(context.Background(), tt.opts...)
+		}()
+	}
+	waitFor(t, func() bool { return c.flights.waiters(server.URL+"/image") == len(tests)+1 }, "stricter fetches should join")
+	close(release)
+	wg.Wait()
+
+	testutil.AssertNoError(t, errs[0], "first fetch should succeed")
+	for i, tt := range tests {
+		testutil.AssertTrue(t, errors.Is(errs[i+1], ErrImageTooLarge), tt.name+" should be ErrImageTooLarge, got "+errString(errs[i+1]))
+	}
+}
+
+// TestFlightGroup_Cancel tests a canceled caller doesn't cancel the fetch
+// for the others, and the last one leaving does
+func TestFlightGroup_Cancel(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	fetchErr := make(chan error, 1)
+	fetch := func(ctx context.Context) (cachedImage, error) {
+		select {
+		case <-release:
+			return cachedImage{format: "png"}, nil
+		case <-ctx.Done():
+			fetchErr <- ctx.Err()
+			return cachedImage{}, ctx.Err()
+		}
+	}
+
+	t.Run("others_keep_waiting", func(t *testing.T) {
+		first, cancel := context.WithCancel(context.Background())
+		firstErr := make(chan error, 1)
+		go func() {
+			_, _, err := g.do(first, "a", fetch)
+			firstErr <- err
+		}()
+		waitFor(t, func() bool { return g.waiters("a") == 1 }, "first caller waiting")
+
+		var val cachedImage
+		var joined bool
+		var err error
+		secondDone := make(chan struct{})
+		go func() {
+			val, joined, err = g.do(context.Background(), "a", fetch)
+			close(secondDone)
+		}()
+		waitFor(t, func() bool { return g.waiters("a") == 2 }, "second caller waiting")
+
+		cancel()
+		testutil.AssertTrue(t, errors.Is(<-firstErr, context.Canceled), "first caller canceled")
+		close(release)
+		<-secondDone
+		testutil.AssertNoError(t, err, "second caller should succeed")
+		testutil.AssertTrue(t, joined, "second caller joins")
+		testutil.AssertEqual(t, "png", val.format, "second caller gets the image")
+	})
+
+	t.Run("last_leaving_cancels", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			_, _, err := g.do(ctx, "b", func(ctx context.Context) (cachedImage, error) {
+				<-ctx.Done()
+				fetchErr <- ctx.Err()
+				return cachedImage{}, ctx.Err()
+			})
+			done <- err
+		}()
+		waitFor(t, func() bool { return g.waiters("b") == 1 }, "caller waiting")
+
+		cancel()
+		testutil.AssertTrue(t, errors.Is(<-done, context.Canceled), "caller canceled")
+		testutil.AssertTrue(t, errors.Is(<-fetchErr, context.Canceled), "fetch canceled")
+		testutil.AssertEqual(t, 0, g.waiters("b"), "flight forgotten")
+	})
+}
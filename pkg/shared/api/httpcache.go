@@ -0,0 +1,401 @@
+package api
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long DiskCache keeps images served without any
+// caching headers. Other responses without them aren't cached, since the
+// metadata of a random cat must not repeat.
+const DefaultCacheTTL = 24 * time.Hour
+
+// DefaultCacheMaxBytes bounds DiskCache unless NewDiskCache is given a size
+const DefaultCacheMaxBytes = 256 << 20
+
+// staleTempAge is how old a temp file has to be before a new DiskCache
+// takes it for the leftover of an interrupted store, younger ones may still
+// be written by another running instance
+const staleTempAge = time.Hour
+
+// cacheTempPrefix starts the names of entries still being written
+const cacheTempPrefix = "tmp-"
+
+// cacheExpiresHeader records when a stored response goes stale
+const cacheExpiresHeader = "X-Catfetch-Cache-Expires"
+
+// DiskCache is an http.RoundTripper that keeps successful GET responses on
+// disk, keyed by URL, for as long as their Cache-Control max-age or Expires
+// header allows. Stale entries are refetched rather than revalidated, and
+// Vary is ignored. Responses are written while the caller reads them, so
+// only fully read bodies are cached. Once the entries outgrow the cache's
+// size, the least recently used are removed.
+type DiskCache struct {
+	dir      string
+	next     http.RoundTripper
+	clock    Clock
+	maxBytes int64
+
+	mu   sync.Mutex
+	size int64 // bytes stored as of the last sweep, plus entries added since
+}
+
+// NewDiskCache stores responses in dir, fetching misses with next, or
+// http.DefaultTransport when nil. The entries are kept within maxBytes, or
+// DefaultCacheMaxBytes when it isn't positive. Expired entries and temp
+// files left by interrupted stores are removed right away. Of opts, only
+// WithClock applies, for the entries' age and expiry.
+func NewDiskCache(dir string, maxBytes int64, next http.RoundTripper, opts ...Option) *DiskCache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultCacheMaxBytes
+	}
+	var clock Clock = realClock{}
+	if o := newOptions(opts); o.clock != nil {
+		clock = o.clock
+	}
+	c := &DiskCache{dir: dir, next: next, clock: clock, maxBytes: maxBytes}
+	c.sweep()
+	return c
+}
+
+// DefaultCacheDir is catfetch/http under the user cache directory, which is
+// $XDG_CACHE_HOME or ~/.cache on Linux
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "catfetch", "http"), nil
+}
+
+// RoundTrip serves fresh responses from disk and stores cacheable ones
+func (c *DiskCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheableRequest(req) {
+		return c.transport().RoundTrip(req)
+	}
+
+	path := c.path(req.URL.String())
+	if resp, ok := c.load(path, req); ok {
+		return resp, nil
+	}
+
+	resp, err := c.transport().RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	ttl, ok := freshness(resp.Header, c.now())
+	if !ok {
+		return resp, nil
+	}
+	// the cache is best effort, the response is good either way
+	_ = c.store(path, resp, c.now().Add(ttl))
+	return resp, nil
+}
+
+func (c *DiskCache) now() time.Time {
+	return c.clock.Now()
+}
+
+func (c *DiskCache) transport() http.RoundTripper {
+	if c.next == nil {
+		return defaultTransport()
+	}
+	return c.next
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// load returns the stored response if it exists and is still fresh, removing
+// it once stale
+func (c *DiskCache) load(path string, req *http.Request) (*http.Response, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(f), req)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	expires, err := strconv.ParseInt(resp.Header.Get(cacheExpiresHeader), 10, 64)
+	if err != nil || !c.now().Before(time.Unix(expires, 0)) {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return nil, false
+	}
+	resp.Header.Del(cacheExpiresHeader)
+	resp.Body = &fileBody{ReadCloser: resp.Body, file: f}
+	// the modification time tells which entries were used least recently
+	now := c.now()
+	_ = os.Chtimes(path, now, now)
+	return resp, true
+}
+
+// cacheEntry is a stored response, used is when it was stored or last served
+type cacheEntry struct {
+	path string
+	size int64
+	used time.Time
+}
+
+// sweep removes expired entries and stale temp files, then the least
+// recently used entries until the rest fit in maxBytes
+func (c *DiskCache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	now := c.now()
+	var entries []cacheEntry
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		path := filepath.Join(c.dir, file.Name())
+		switch {
+		case strings.HasPrefix(file.Name(), cacheTempPrefix):
+			if now.Sub(info.ModTime()) >= staleTempAge {
+				_ = os.Remove(path)
+			}
+		case c.expired(path, now):
+			_ = os.Remove(path)
+		default:
+			entries = append(entries, cacheEntry{path: path, size: info.Size(), used: info.ModTime()})
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b cacheEntry) int { return a.used.Compare(b.used) })
+	c.size = 0
+	for _, e := range entries {
+		c.size += e.size
+	}
+	for _, e := range entries {
+		if c.size <= c.maxBytes {
+			break
+		}
+		if os.Remove(e.path) == nil {
+			c.size -= e.size
+		}
+	}
+}
+
+// expired reports whether the entry at path is stale at now, or unreadable
+func (c *DiskCache) expired(path string, now time.Time) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	// only the status line and headers are read
+	resp, err := http.ReadResponse(bufio.NewReader(f), nil)
+	if err != nil {
+		return true
+	}
+	expires, err := strconv.ParseInt(resp.Header.Get(cacheExpiresHeader), 10, 64)
+	return err != nil || !now.Before(time.Unix(expires, 0))
+}
+
+// added counts an entry of n bytes just stored, sweeping once the cache
+// outgrew its size
+func (c *DiskCache) added(path string, n int64) {
+	now := c.now()
+	_ = os.Chtimes(path, now, now)
+
+	c.mu.Lock()
+	c.size += n
+	over := c.size > c.maxBytes
+	c.mu.Unlock()
+	if over {
+		c.sweep()
+	}
+}
+
+// store writes the status and headers now and tees the body into the entry
+// as it is read. The entry only appears once the body reached EOF.
+func (c *DiskCache) store(path string, resp *http.Response, expires time.Time) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.dir, cacheTempPrefix+"*")
+	if err != nil {
+		return err
+	}
+
+	header := resp.Header.Clone()
+	// the body is stored plain and read back until EOF
+	header.Del("Content-Length")
+	header.Del("Transfer-Encoding")
+	header.Set(cacheExpiresHeader, strconv.FormatInt(expires.Unix(), 10))
+
+	_, err = fmt.Fprintf(f, "HTTP/1.1 %s\r\n", resp.Status)
+	if err == nil {
+		err = header.Write(f)
+	}
+	if err == nil {
+		_, err = io.WriteString(f, "\r\n")
+	}
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	resp.Body = &cachingBody{ReadCloser: resp.Body, file: f, path: path, cache: c}
+	return nil
+}
+
+// cacheableRequest reports whether req may be answered from or stored in
+// the cache
+func cacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return false
+	}
+	directives := cacheControl(req.Header)
+	_, noStore := directives["no-store"]
+	_, noCache := directives["no-cache"]
+	return !noStore && !noCache
+}
+
+// freshness is how long a response may be reused, and false when it mustn't
+// be stored at all
+func freshness(header http.Header, now time.Time) (time.Duration, bool) {
+	directives := cacheControl(header)
+	for _, d := range []string{"no-store", "no-cache"} {
+		if _, ok := directives[d]; ok {
+			return 0, false
+		}
+	}
+	if v, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if v := header.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0, false
+		}
+		// measure from the server's clock when it sent one
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			now = date
+		}
+		ttl := expires.Sub(now)
+		if ttl <= 0 {
+			return 0, false
+		}
+		return ttl, true
+	}
+	if len(directives) == 0 {
+		mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		if strings.HasPrefix(mediaType, "image/") {
+			return DefaultCacheTTL, true
+		}
+	}
+	return 0, false
+}
+
+// cacheControl parses the Cache-Control directives into lowercase names and
+// their unquoted values
+func cacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, line := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return directives
+}
+
+// fileBody closes the cache file along with the body read from it
+type fileBody struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (b *fileBody) Close() error {
+	err := b.ReadCloser.Close()
+	if ferr := b.file.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// cachingBody copies what is read into a temp file, moving it into place at
+// EOF and discarding it when closed early or when writing fails
+type cachingBody struct {
+	io.ReadCloser
+	file  *os.File
+	path  string
+	cache *DiskCache
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.file != nil {
+		if _, werr := b.file.Write(p[:n]); werr != nil {
+			b.discard()
+		}
+	}
+	if err == io.EOF && b.file != nil {
+		b.commit()
+	}
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	b.discard()
+	return b.ReadCloser.Close()
+}
+
+func (b *cachingBody) commit() {
+	name := b.file.Name()
+	info, err := b.file.Stat()
+	if cerr := b.file.Close(); err == nil {
+		err = cerr
+	}
+	b.file = nil
+	if err == nil {
+		err = os.Rename(name, b.path)
+	}
+	if err != nil {
+		_ = os.Remove(name)
+		return
+	}
+	b.cache.added(b.path, info.Size())
+}
+
+func (b *cachingBody) discard() {
+	if b.file == nil {
+		return
+	}
+	_ = b.file.Close()
+	_ = os.Remove(b.file.Name())
+	b.file = nil
+}
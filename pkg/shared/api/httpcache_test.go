@@ -0,0 +1,217 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// cacheServer counts requests per path and answers with the Cache-Control
+// given in the cc query param
+func cacheServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if cc := r.URL.Query().Get("cc"); cc != "" {
+			w.Header().Set("Cache-Control", cc)
+		}
+		switch r.URL.Path {
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(testutil.ValidPNGBytes())
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testutil.ValidMetadataJSON()))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+// get reads the whole body through the cache
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	testutil.AssertNoError(t, err, "GET should succeed")
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	testutil.AssertNoError(t, err, "reading the body should succeed")
+	testutil.AssertEqual(t, http.StatusOK, resp.StatusCode, "status")
+	return string(body)
+}
+
+// TestDiskCache_RoundTrip tests which responses are served from disk
+func TestDiskCache_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		wantHits int32
+	}{
+		{"image_without_headers", "/image", 1},
+		{"max_age", "/cat?cc=max-age=60", 1},
+		{"json_without_headers", "/cat", 2},
+		{"no_store", "/image?cc=no-store", 2},
+		{"no_cache", "/image?cc=no-cache", 2},
+		{"zero_max_age", "/image?cc=max-age=0", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, hits := cacheServer(t)
+			client := &http.Client{Transport: NewDiskCache(t.TempDir(), 0, nil)}
+
+			first := get(t, client, server.URL+tt.path)
+			second := get(t, client, server.URL+tt.path)
+			testutil.AssertEqual(t, first, second, "cached body")
+			testutil.AssertEqual(t, tt.wantHits, hits.Load(), "requests reaching the server")
+		})
+	}
+}
+
+// TestDiskCache_Expiry tests stale entries are refetched
+func TestDiskCache_Expiry(t *testing.T) {
+	server, hits := cacheServer(t)
+	clock := &fakeClock{t: time.Now()}
+	client := &http.Client{Transport: NewDiskCache(t.TempDir(), 0, nil, WithClock(clock))}
+
+	get(t, client, server.URL+"/image?cc=max-age=60")
+	clock.advance(30 * time.Second)
+	get(t, client, server.URL+"/image?cc=max-age=60")
+	testutil.AssertEqual(t, int32(1), hits.Load(), "fresh entry should be reused")
+
+	clock.advance(time.Minute)
+	get(t, client, server.URL+"/image?cc=max-age=60")
+	testutil.AssertEqual(t, int32(2), hits.Load(), "stale entry should be refetched")
+}
+
+// TestDiskCache_PartialRead tests bodies closed early aren't cached
+func TestDiskCache_PartialRead(t *testing.T) {
+	server, hits := cacheServer(t)
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewDiskCache(dir, 0, nil)}
+
+	resp, err := client.Get(server.URL + "/image")
+	testutil.AssertNoError(t, err, "GET should succeed")
+	_, _ = resp.Body.Read(make([]byte, 4))
+	resp.Body.Close()
+
+	entries, err := os.ReadDir(dir)
+	testutil.AssertNoError(t, err, "reading the cache dir should succeed")
+	testutil.AssertEqual(t, 0, len(entries), "nothing left on disk")
+
+	get(t, client, server.URL+"/image")
+	testutil.AssertEqual(t, int32(2), hits.Load(), "partial body shouldn't be reused")
+}
+
+// TestDiskCache_Sweep tests a new cache removes expired entries and temp
+// files left by interrupted stores
+func TestDiskCache_Sweep(t *testing.T) {
+	server, _ := cacheServer(t)
+	dir := t.TempDir()
+	cache := NewDiskCache(dir, 0, nil, WithClock(&fakeClock{t: time.Now().Add(-2 * time.Minute)}))
+	client := &http.Client{Transport: cache}
+	get(t, client, server.URL+"/image?cc=max-age=60")
+	get(t, client, server.URL+"/image")
+
+	for _, name := range []string{"tmp-old", "tmp-young"} {
+		testutil.AssertNoError(t, os.WriteFile(filepath.Join(dir, name), []byte("partial"), 0o600), "writing a temp file")
+	}
+	old := time.Now().Add(-2 * staleTempAge)
+	testutil.AssertNoError(t, os.Chtimes(filepath.Join(dir, "tmp-old"), old, old), "aging a temp file")
+
+	NewDiskCache(dir, 0, nil)
+	files, err := os.ReadDir(dir)
+	testutil.AssertNoError(t, err, "reading the cache dir should succeed")
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	testutil.AssertEqual(t, 2, len(names), "fresh entry and young temp file left")
+	testutil.AssertTrue(t, slices.Contains(names, "tmp-young"), "temp file possibly in use kept")
+	testutil.AssertTrue(t, slices.Contains(names, filepath.Base(cache.path(server.URL+"/image"))), "fresh entry kept")
+}
+
+// TestDiskCache_MaxBytes tests the least recently used entries are removed
+// once the cache outgrows its size
+func TestDiskCache_MaxBytes(t *testing.T) {
+	server, hits := cacheServer(t)
+
+	// entries only differ in the URL, which isn't stored, so measure one
+	probe := NewDiskCache(t.TempDir(), 0, nil)
+	get(t, &http.Client{Transport: probe}, server.URL+"/image?n=0")
+	info, err := os.Stat(probe.path(server.URL + "/image?n=0"))
+	testutil.AssertNoError(t, err, "stat the probe entry")
+	hits.Store(0)
+
+	clock := &fakeClock{t: time.Now()}
+	cache := NewDiskCache(t.TempDir(), info.Size()*5/2, nil, WithClock(clock))
+	client := &http.Client{Transport: cache}
+
+	get(t, client, server.URL+"/image?n=1")
+	clock.advance(time.Minute)
+	get(t, client, server.URL+"/image?n=2")
+	clock.advance(time.Minute)
+	get(t, client, server.URL+"/image?n=1")
+	clock.advance(time.Minute)
+	get(t, client, server.URL+"/image?n=3")
+	testutil.AssertEqual(t, int32(3), hits.Load(), "the repeated image came from disk")
+
+	get(t, client, server.URL+"/image?n=1")
+	get(t, client, server.URL+"/image?n=3")
+	testutil.AssertEqual(t, int32(3), hits.Load(), "recently used entries kept")
+	get(t, client, server.URL+"/image?n=2")
+	testutil.AssertEqual(t, int32(4), hits.Load(), "least recently used entry removed")
+}
+
+// TestDiskCache_Client tests a client fetching the same image twice
+func TestDiskCache_Client(t *testing.T) {
+	server := catServer(t)
+	transport := &countingTransport{next: http.DefaultTransport}
+	c := NewClient(&http.Client{Transport: NewDiskCache(t.TempDir(), 0, transport)}, WithBaseURL(server.URL))
+
+	for range 2 {
+		img, _, err := c.RandomCat()
+		testutil.AssertNoError(t, err, "RandomCat should succeed")
+		testutil.AssertNotNil(t, img, "image")
+	}
+	testutil.AssertEqual(t, int32(3), transport.count.Load(), "metadata twice, image once")
+}
+
+// TestFreshness tests parsing the caching headers
+func TestFreshness(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		wantTTL time.Duration
+		wantOK  bool
+	}{
+		{"max_age", http.Header{"Cache-Control": {"public, max-age=120"}}, 2 * time.Minute, true},
+		{"quoted_max_age", http.Header{"Cache-Control": {`max-age="60"`}}, time.Minute, true},
+		{"private_no_store", http.Header{"Cache-Control": {"private, no-store"}}, 0, false},
+		{"expires", http.Header{"Expires": {"Mon, 01 Jan 2024 13:00:00 GMT"}}, time.Hour, true},
+		{"expires_from_date", http.Header{"Expires": {"Mon, 01 Jan 2024 13:00:00 GMT"}, "Date": {"Mon, 01 Jan 2024 12:30:00 GMT"}}, 30 * time.Minute, true},
+		{"expired", http.Header{"Expires": {"Mon, 01 Jan 2024 11:00:00 GMT"}}, 0, false},
+		{"bad_expires", http.Header{"Expires": {"0"}}, 0, false},
+		{"image", http.Header{"Content-Type": {"image/jpeg"}}, DefaultCacheTTL, true},
+		{"json", http.Header{"Content-Type": {"application/json"}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, ok := freshness(tt.header, now)
+			testutil.AssertEqual(t, tt.wantOK, ok, "cacheable")
+			testutil.AssertEqual(t, tt.wantTTL, ttl, "ttl")
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"container/list"
+	"image"
+	"sync"
+)
+
+// ImageCache is a least recently used cache of decoded images, so showing a
+// cat again doesn't download and decode it again. It is bounded by entry
+// count and by the approximate memory held by the pixels and downloaded
+// bytes, a zero limit disables that bound. Cached images are shared and must not be modified.
+// It is safe for concurrent use.
+type ImageCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	order      *list.List // most recently used at the front
+	entries    map[string]*list.Element
+	bytes      int64
+	hits       uint64
+	misses     uint64
+}
+
+// CacheStats is a snapshot of an ImageCache
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+	Bytes   int64 // approximate memory held by the cached images
+}
+
+// cachedImage is a decoded image with the bytes it was decoded from
+type cachedImage struct {
+	img    image.Image
+	format string
+	data   []byte
+}
+
+type imageCacheEntry struct {
+	key   string
+	value cachedImage
+	bytes int64
+}
+
+// NewImageCache returns an empty cache holding at most maxEntries images
+// and maxBytes of memory
+func NewImageCache(maxEntries int, maxBytes int64) *ImageCache {
+	return &ImageCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+	}
+}
+
+// WithImageCache keeps decoded images in cache, keyed by image URL. The URL
+// carries the cat ID and any scaling and filter params, so each variant of a
+// cat is cached on its own.
+func WithImageCache(cache *ImageCache) Option {
+	return func(o *options) {
+		o.imageCache = cache
+	}
+}
+
+// Get returns the image stored under key, marking it recently used
+func (c *ImageCache) Get(key string) (image.Image, bool) {
+	v, ok := c.get(key)
+	return v.img, ok
+}
+
+// Add stores img under key along with the bytes it was decoded from and
+// their format, which fetches hitting the entry return for saving. It evicts
+// the least recently used images to stay within the limits. An image larger
+// than maxBytes, or without its bytes or format, isn't stored.
+func (c *ImageCache) Add(key string, img image.Image, data []byte, format string) {
+	c.add(key, cachedImage{img: img, format: format, data: data})
+}
+
+// Stats returns the hit and miss counts and the current size
+func (c *ImageCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.order.Len(),
+		Bytes:   c.bytes,
+	}
+}
+
+// get is Get with the download details, a nil cache always misses
+func (c *ImageCache) get(key string) (cachedImage, bool) {
+	if c == nil {
+		return cachedImage{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return cachedImage{}, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*imageCacheEntry).value, true
+}
+
+// add is Add with the download details, a nil cache stores nothing
+func (c *ImageCache) add(key string, value cachedImage) {
+	if c == nil || value.img == nil || len(value.data) == 0 || value.format == "" {
+		return
+	}
+	size := imageBytes(value.img) + int64(len(value.data))
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.removeElement(el)
+	}
+	c.entries[key] = c.order.PushFront(&imageCacheEntry{key: key, value: value, bytes: size})
+	c.bytes += size
+
+	for (c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *ImageCache) removeElement(el *list.Element) {
+	entry := c.order.Remove(el).(*imageCacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.bytes
+}
+
+// imageBytes approximates the memory held by the pixels of img
+func imageBytes(img image.Image) int64 {
+	switch m := img.(type) {
+	case *image.RGBA:
+		return int64(len(m.Pix))
+	case *image.NRGBA:
+		return int64(len(m.Pix))
+	case *image.Gray:
+		return int64(len(m.Pix))
+	case *image.Paletted:
+		return int64(len(m.Pix))
+	case *image.YCbCr:
+		return int64(len(m.Y) + len(m.Cb) + len(m.Cr))
+	default:
+		b := img.Bounds()
+		return int64(b.Dx()) * int64(b.Dy()) * 4
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"image"
+	"net/http"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// oneByte stands in for the downloaded bytes of cached test images
+var oneByte = []byte{0}
+
+// TestImageCache_Entries tests evicting the least recently used image
+func TestImageCache_Entries(t *testing.T) {
+	c := NewImageCache(2, 0)
+	c.Add("a", testutil.CreateColorImage(1, 1, 1, 1, 1), oneByte, "png")
+	c.Add("b", testutil.CreateColorImage(1, 1, 2, 2, 2), oneByte, "png")
+
+	_, ok := c.Get("a")
+	testutil.AssertTrue(t, ok, "a should be cached")
+
+	c.Add("c", testutil.CreateColorImage(1, 1, 3, 3, 3), oneByte, "png")
+	_, ok = c.Get("b")
+	testutil.AssertTrue(t, !ok, "b should be evicted as least recently used")
+	_, ok = c.Get("a")
+	testutil.AssertTrue(t, ok, "a should survive")
+
+	stats := c.Stats()
+	testutil.AssertEqual(t, uint64(2), stats.Hits, "hits")
+	testutil.AssertEqual(t, uint64(1), stats.Misses, "misses")
+	testutil.AssertEqual(t, 2, stats.Entries, "entries")
+	testutil.AssertEqual(t, int64(10), stats.Bytes, "pixels and downloaded bytes")
+}
+
+// TestImageCache_Bytes tests the memory bound
+func TestImageCache_Bytes(t *testing.T) {
+	c := NewImageCache(0, 100)
+	c.Add("a", testutil.CreateColorImage(3, 3, 1, 1, 1), oneByte, "png") // 37 bytes
+	c.Add("b", testutil.CreateColorImage(3, 3, 2, 2, 2), oneByte, "png")
+	c.Add("c", testutil.CreateColorImage(3, 3, 3, 3, 3), oneByte, "png")
+	testutil.AssertEqual(t, 2, c.Stats().Entries, "third image evicts the first")
+	testutil.AssertEqual(t, int64(74), c.Stats().Bytes, "bytes")
+
+	c.Add("huge", testutil.CreateColorImage(10, 10, 4, 4, 4), oneByte, "png")
+	_, ok := c.Get("huge")
+	testutil.AssertTrue(t, !ok, "an image over the limit isn't stored")
+	testutil.AssertEqual(t, 2, c.Stats().Entries, "others kept")
+
+	// replacing a key doesn't count it twice
+	c.Add("b", testutil.CreateColorImage(3, 3, 5, 5, 5), oneByte, "png")
+	testutil.AssertEqual(t, int64(74), c.Stats().Bytes, "bytes after replace")
+}
+
+// TestImageCache_Add tests entries keep what fetches return for saving
+func TestImageCache_Add(t *testing.T) {
+	c := NewImageCache(0, 0)
+	data := testutil.ValidPNGBytes()
+	c.Add("a", testutil.CreateColorImage(1, 1, 1, 1, 1), data, "png")
+
+	cached, ok := c.get("a")
+	testutil.AssertTrue(t, ok, "a should be cached")
+	testutil.AssertTrue(t, bytes.Equal(data, cached.data), "downloaded bytes kept")
+	testutil.AssertEqual(t, "png", cached.format, "format kept")
+
+	c.Add("no_bytes", testutil.CreateColorImage(1, 1, 1, 1, 1), nil, "png")
+	c.Add("no_format", testutil.CreateColorImage(1, 1, 1, 1, 1), data, "")
+	testutil.AssertEqual(t, 1, c.Stats().Entries, "entries without bytes or format aren't stored")
+}
+
+// TestImageBytes tests the pixel memory estimate
+func TestImageBytes(t *testing.T) {
+	r := image.Rect(0, 0, 4, 2)
+	testutil.AssertEqual(t, int64(32), imageBytes(image.NewNRGBA(r)), "NRGBA")
+	testutil.AssertEqual(t, int64(8), imageBytes(image.NewGray(r)), "Gray")
+	testutil.AssertEqual(t, int64(12), imageBytes(image.NewYCbCr(r, image.YCbCrSubsampleRatio420)), "YCbCr 4:2:0")
+	testutil.AssertEqual(t, int64(32), imageBytes(image.NewRGBA64(r)), "other types as 4 bytes per pixel")
+}
+
+// TestClient_ImageCache tests a cached image skips the download
+func TestClient_ImageCache(t *testing.T) {
+	server := catServer(t)
+	transport := &countingTransport{next: http.DefaultTransport}
+	cache := NewImageCache(10, 0)
+	c := NewClient(&http.Client{Transport: transport}, WithBaseURL(server.URL), WithImageCache(cache))
+
+	first, err := c.FetchByID(t.Context(), "abc")
+	testutil.AssertNoError(t, err, "first fetch should succeed")
+	second, err := c.FetchByID(t.Context(), "abc")
+	testutil.AssertNoError(t, err, "second fetch should succeed")
+
+	testutil.AssertTrue(t, first.Image == second.Image, "same decoded image")
+	testutil.AssertEqual(t, len(testutil.ValidPNGBytes()), len(second.Bytes), "downloaded bytes kept")
+	testutil.AssertEqual(t, first.Metadata.GetSize(), second.Metadata.GetSize(), "size kept from the download")
+	testutil.AssertEqual(t, int32(3), transport.count.Load(), "metadata twice, image once")
+	testutil.AssertEqual(t, uint64(1), cache.Stats().Hits, "hits")
+	testutil.AssertEqual(t, uint64(1), cache.Stats().Misses, "misses")
+}
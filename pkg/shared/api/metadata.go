@@ -8,6 +8,27 @@ type CatMetadata struct {
 	CreatedAt time.Time `json:"created_at"`
 	URL       string    `json:"url"`
 	MIMEType  string    `json:"mimetype"`
+	// Breeds is filled by providers that know the breed, cataas doesn't
+	Breeds []Breed `json:"breeds,omitempty"`
+	// AutoTags are derived locally from the image by WithAutoTags, separate
+	// from the tags the server knows about
+	AutoTags []string `json:"auto_tags,omitempty"`
+
+	// RequestID identifies the fetch that produced this metadata, it is not part of the API response
+	RequestID string `json:"-"`
+	// Size is the number of bytes downloaded for the image
+	Size int `json:"-"`
+}
+
+// Breed describes a cat breed
+type Breed struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Origin       string `json:"origin"`
+	Temperament  string `json:"temperament"`
+	Description  string `json:"description"`
+	LifeSpan     string `json:"life_span"`
+	WikipediaURL string `json:"wikipedia_url"`
 }
 
 func (cm *CatMetadata) GetID() string {
@@ -29,3 +50,19 @@ func (cm *CatMetadata) GetURL() string {
 func (cm *CatMetadata) GetMIMEType() string {
 	return cm.MIMEType
 }
+
+func (cm *CatMetadata) GetRequestID() string {
+	return cm.RequestID
+}
+
+func (cm *CatMetadata) GetSize() int {
+	return cm.Size
+}
+
+func (cm *CatMetadata) GetBreeds() []Breed {
+	return cm.Breeds
+}
+
+func (cm *CatMetadata) GetAutoTags() []string {
+	return cm.AutoTags
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the request latency histogram
+var LatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// MetricsSnapshot is a copy of the counters kept by every client and
+// provider in the process
+type MetricsSnapshot struct {
+	Requests        uint64            // HTTP requests sent, counting each retry
+	Fetches         uint64            // cats asked for
+	FetchErrors     map[string]uint64 // failed fetches by ErrorKind
+	BytesDownloaded uint64            // response bytes read
+	Latency         LatencySnapshot   // time until response headers, per request
+}
+
+// LatencySnapshot is a histogram of request latencies. Counts[i] is the
+// number of requests that took at most LatencyBuckets[i] and longer than the
+// bucket before, the last count is for those slower than every bucket.
+type LatencySnapshot struct {
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+type metrics struct {
+	mu        sync.Mutex
+	requests  uint64
+	fetches   uint64
+	errors    map[string]uint64
+	bytes     uint64
+	latencies []uint64
+	latSum    time.Duration
+}
+
+var apiMetrics = &metrics{
+	errors:    map[string]uint64{},
+	latencies: make([]uint64, len(LatencyBuckets)+1),
+}
+
+// Metrics returns a snapshot of the API metrics
+func Metrics() MetricsSnapshot {
+	return apiMetrics.snapshot()
+}
+
+func (m *metrics) snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var count uint64
+	for _, n := range m.latencies {
+		count += n
+	}
+	return MetricsSnapshot{
+		Requests:        m.requests,
+		Fetches:         m.fetches,
+		FetchErrors:     maps.Clone(m.errors),
+		BytesDownloaded: m.bytes,
+		Latency: LatencySnapshot{
+			Counts: slices.Clone(m.latencies),
+			Count:  count,
+			Sum:    m.latSum,
+		},
+	}
+}
+
+func (m *metrics) observeRequest(latency time.Duration) {
+	i, _ := slices.BinarySearch(LatencyBuckets, latency)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	m.latencies[i]++
+	m.latSum += latency
+}
+
+func (m *metrics) observeFetch(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches++
+	if err != nil {
+		m.errors[ErrorKind(err)]++
+	}
+}
+
+func (m *metrics) addBytes(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes += uint64(n)
+}
+
+// ErrorKind sorts a fetch error into a broad kind for metrics: "timeout",
+// "canceled", "status_4xx", "status_5xx", "too_large", "decode",
+// "metadata", "network" or "other"
+func ErrorKind(err error) string {
+	var statusErr *StatusError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, ErrTimeout), isTimeout(err):
+		return "timeout"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("status_%dxx", statusErr.StatusCode/100)
+	case errors.Is(err, ErrImageTooLarge):
+		return "too_large"
+	case errors.Is(err, ErrDecode), errors.Is(err, image.ErrFormat):
+		return "decode"
+	case errors.Is(err, ErrMetadata):
+		return "metadata"
+	case errors.Is(err, ErrNetwork), errors.As(err, &netErr):
+		return "network"
+	default:
+		return "other"
+	}
+}
+
+// countingBody adds what is read from a response body to the metrics
+type countingBody struct {
+	io.ReadCloser
+}
+
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	apiMetrics.addBytes(n)
+	return n, err
+}
+
+// MetricsHandler serves the metrics in the Prometheus text format
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_ = WriteMetrics(w)
+	})
+}
+
+// WriteMetrics writes the metrics in the Prometheus text format
+func WriteMetrics(w io.Writer) error {
+	s := Metrics()
+	p := &metricsPrinter{w: w}
+
+	p.printf("# HELP catfetch_http_requests_total HTTP requests sent, counting retries.\n")
+	p.printf("# TYPE catfetch_http_requests_total counter\n")
+	p.printf("catfetch_http_requests_total %d\n", s.Requests)
+
+	p.printf("# HELP catfetch_fetches_total Cats asked for.\n")
+	p.printf("# TYPE catfetch_fetches_total counter\n")
+	p.printf("catfetch_fetches_total %d\n", s.Fetches)
+
+	p.printf("# HELP catfetch_fetch_errors_total Failed fetches by kind.\n")
+	p.printf("# TYPE catfetch_fetch_errors_total counter\n")
+	for _, kind := range slices.Sorted(maps.Keys(s.FetchErrors)) {
+		p.printf("catfetch_fetch_errors_total{kind=%q} %d\n", kind, s.FetchErrors[kind])
+	}
+
+	p.printf("# HELP catfetch_downloaded_bytes_total Response bytes read.\n")
+	p.printf("# TYPE catfetch_downloaded_bytes_total counter\n")
+	p.printf("catfetch_downloaded_bytes_total %d\n", s.BytesDownloaded)
+
+	p.printf("# HELP catfetch_http_request_duration_seconds Time until response headers.\n")
+	p.printf("# TYPE catfetch_http_request_duration_seconds histogram\n")
+	var cumulative uint64
+	for i, bound := range LatencyBuckets {
+		cumulative += s.Latency.Counts[i]
+		le := strconv.FormatFloat(bound.Seconds(), 'g', -1, 64)
+		p.printf("catfetch_http_request_duration_seconds_bucket{le=%q} %d\n", le, cumulative)
+	}
+	p.printf("catfetch_http_request_duration_seconds_bucket{le=\"+Inf\"} %d\n", s.Latency.Count)
+	p.printf("catfetch_http_request_duration_seconds_sum %g\n", s.Latency.Sum.Seconds())
+	p.printf("catfetch_http_request_duration_seconds_count %d\n", s.Latency.Count)
+
+	return p.err
+}
+
+// metricsPrinter keeps the first write error so the output reads straight
+type metricsPrinter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *metricsPrinter) printf(format string, args ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
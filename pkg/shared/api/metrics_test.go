@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestMetrics_Fetch tests a fetch is counted
+func TestMetrics_Fetch(t *testing.T) {
+	server := catServer(t)
+	c := NewClient(nil, WithBaseURL(server.URL))
+
+	before := Metrics()
+	_, meta, err := c.RandomCat()
+	testutil.AssertNoError(t, err, "RandomCat should succeed")
+	after := Metrics()
+
+	testutil.AssertEqual(t, uint64(2), after.Requests-before.Requests, "metadata and image requests")
+	testutil.AssertEqual(t, uint64(1), after.Fetches-before.Fetches, "fetches")
+	testutil.AssertEqual(t, uint64(2), after.Latency.Count-before.Latency.Count, "latency observations")
+	testutil.AssertTrue(t, after.BytesDownloaded-before.BytesDownloaded > uint64(meta.GetSize()), "image and metadata bytes")
+	testutil.AssertEqual(t, len(LatencyBuckets)+1, len(after.Latency.Counts), "bucket count")
+}
+
+// TestMetrics_Errors tests failed fetches are counted by kind
+func TestMetrics_Errors(t *testing.T) {
+	server, _ := flakyServer(t, 1, http.StatusNotFound)
+
+	before := Metrics()
+	_, _, err := RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithoutRetries())
+	testutil.AssertError(t, err, "404 should fail")
+	after := Metrics()
+
+	testutil.AssertEqual(t, uint64(1), after.FetchErrors["status_4xx"]-before.FetchErrors["status_4xx"], "4xx errors")
+}
+
+// TestErrorKind tests sorting errors into kinds
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("wrapped: %w", context.Canceled), "canceled"},
+		{context.DeadlineExceeded, "timeout"},
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, "status_4xx"},
+		{wrapRequestError("abc", &StatusError{StatusCode: http.StatusBadGateway}), "status_5xx"},
+		{fmt.Errorf("%w: body", ErrImageTooLarge), "too_large"},
+		{image.ErrFormat, "decode"},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, "network"},
+		{ErrNoImageURL, "metadata"},
+		{ErrNoID, "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, ErrorKind(tt.err), "kind")
+		})
+	}
+}
+
+// TestMetricsHandler tests the Prometheus text output
+func TestMetricsHandler(t *testing.T) {
+	apiMetrics.observeFetch(&StatusError{StatusCode: http.StatusServiceUnavailable})
+
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE catfetch_http_requests_total counter\n",
+		"catfetch_fetch_errors_total{kind=\"status_5xx\"} ",
+		"catfetch_http_request_duration_seconds_bucket{le=\"0.05\"} ",
+		"catfetch_http_request_duration_seconds_bucket{le=\"+Inf\"} ",
+		"catfetch_http_request_duration_seconds_count ",
+	} {
+		testutil.AssertTrue(t, strings.Contains(body, want), "output should contain "+want)
+	}
+	testutil.AssertTrue(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain"), "content type")
+}
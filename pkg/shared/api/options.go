@@ -0,0 +1,398 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	caasHost         = "https://cataas.com"
+	caasCatEndpoint  = "/cat"
+	caasTagsEndpoint = "/api/tags"
+)
+
+// DefaultTimeout is the timeout of clients created without an http.Client
+const DefaultTimeout = 30 * time.Second
+
+// DefaultUserAgent identifies requests made without WithUserAgent
+const DefaultUserAgent = "catfetch (+https://github.com/bmj2728/catfetch)"
+
+// DefaultMaxBodySize caps image downloads unless WithMaxBodySize is given
+const DefaultMaxBodySize = 20 << 20
+
+// Option configures where and how the API functions and the CatURL builder
+// talk to cataas
+type Option func(*options)
+
+type options struct {
+	baseURL      string // scheme and host, no trailing slash
+	endpoint     string // path of the cat endpoint, leading slash or empty
+	catID        string
+	tags         []string
+	timeout      time.Duration
+	hasTimeout   bool
+	timeouts     Timeouts
+	tracing      bool
+	timings      *timingLog // set for each fetch when tracing
+	retry        RetryPolicy
+	tagsTTL      time.Duration
+	maxBody      int64
+	decodeLimits DecodeLimits
+	autoTags     bool
+	imageCache   *ImageCache
+	logger       *slog.Logger
+	userAgent    string
+	header       http.Header
+
+	// time and randomness, for tests
+	clock Clock
+	rand  *lockedRand
+
+	// hosts images may come from besides the base URL's
+	allowedHosts []string
+
+	// server-side scaling
+	width      int
+	height     int
+	imgType    CAASImageType
+	hasImgType bool
+
+	// server-side filters
+	filter    CAASImageFilter
+	hasFilter bool
+	custom    CustomFilter
+	blur      int
+
+	// animated cats only
+	gif bool
+
+	// text overlay
+	says      string
+	hasSays   bool
+	fontSize  int
+	fontColor string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		baseURL:      caasHost,
+		endpoint:     caasCatEndpoint,
+		tagsTTL:      DefaultTagsTTL,
+		retry:        DefaultRetryPolicy,
+		maxBody:      DefaultMaxBodySize,
+		decodeLimits: defaultDecodeLimits,
+		userAgent:    DefaultUserAgent,
+		timeouts:     DefaultTimeouts,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithBaseURL points requests at another cataas instance, e.g. a self-hosted
+// mirror at https://cats.example.com
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithEndpoint overrides the path of the cat endpoint, "/cat" by default.
+// An empty endpoint serves cats from the root of the base URL.
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) {
+		endpoint = strings.Trim(endpoint, "/")
+		if endpoint == "" {
+			o.endpoint = ""
+			return
+		}
+		o.endpoint = "/" + endpoint
+	}
+}
+
+// WithTags limits fetched cats to those carrying all the given tags
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithGIF limits fetched cats to animated GIFs. Only the first frame is
+// decoded, FetchResult.Bytes holds the whole animation.
+func WithGIF() Option {
+	return func(o *options) {
+		o.gif = true
+	}
+}
+
+// WithTagsTTL sets how long ListTags may reuse a fetched tag list, zero
+// forces a refresh
+func WithTagsTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.tagsTTL = ttl
+	}
+}
+
+// WithTimeout overrides the timeout of the underlying http.Client
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+		o.hasTimeout = true
+	}
+}
+
+// WithLogger sends logs to logger rather than slog.Default(). Each request
+// is logged at debug level, retries and fallbacks at info, and failures at
+// warn.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// log is the logger given by WithLogger, or the current default
+func (o *options) log() *slog.Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return slog.Default()
+}
+
+// UserAgent is the User-Agent for the given app version, e.g.
+// "catfetch/1.2.0 (+https://github.com/bmj2728/catfetch)"
+func UserAgent(version string) string {
+	return "catfetch/" + strings.TrimPrefix(version, "v") + " (+https://github.com/bmj2728/catfetch)"
+}
+
+// WithUserAgent sets the User-Agent sent with every request, cataas asks
+// clients to identify themselves. An empty string keeps the default.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		if userAgent != "" {
+			o.userAgent = userAgent
+		}
+	}
+}
+
+// WithHeader adds a header sent with every request, e.g. to tag traffic for
+// a debugging proxy. It may be given more than once and overrides the
+// User-Agent, but not the request ID.
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// setHeaders sets the User-Agent, the headers given by WithHeader, the
+// provider's extra headers and the request ID on req, later ones winning
+func (o *options) setHeaders(req *http.Request, extra http.Header, requestID string) {
+	req.Header.Set("User-Agent", o.userAgent)
+	for _, h := range []http.Header{o.header, extra} {
+		for key, values := range h {
+			req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	}
+	req.Header.Set(RequestIDHeader, requestID)
+}
+
+// WithMaxBodySize caps the bytes read for an image, larger downloads fail
+// with ErrImageTooLarge. Sizes below 1 are ignored.
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxBody = n
+		}
+	}
+}
+
+// WithFontSize sets the size of the text drawn by RequestCatSaying
+func WithFontSize(size int) Option {
+	return func(o *options) {
+		o.fontSize = size
+	}
+}
+
+// WithFontColor sets the color of the text drawn by RequestCatSaying, as a
+// hex color such as "#ff79c6"
+func WithFontColor(hexColor string) Option {
+	return func(o *options) {
+		o.fontColor = hexColor
+	}
+}
+
+// WithWidth asks for the image scaled to the given width in pixels
+func WithWidth(width int) Option {
+	return func(o *options) {
+		o.width = width
+	}
+}
+
+// WithHeight asks for the image scaled to the given height in pixels
+func WithHeight(height int) Option {
+	return func(o *options) {
+		o.height = height
+	}
+}
+
+// WithImageType asks for one of the pre-scaled sizes, e.g. CAASImageTypeSmall
+func WithImageType(imgType CAASImageType) Option {
+	return func(o *options) {
+		o.imgType = imgType
+		o.hasImgType = true
+	}
+}
+
+// CustomFilter holds the params used with CAASImageFilterCustom. Zero values
+// are left out so cataas uses its defaults.
+type CustomFilter struct {
+	R, G, B    int
+	Brightness int
+	Saturation int
+	Hue        int
+	Lightness  int
+}
+
+// WithFilter applies one of the cataas filters, e.g. CAASImageFilterMono
+func WithFilter(filter CAASImageFilter) Option {
+	return func(o *options) {
+		o.filter = filter
+		o.hasFilter = true
+	}
+}
+
+// WithCustomFilter applies the custom filter with the given params
+func WithCustomFilter(custom CustomFilter) Option {
+	return func(o *options) {
+		o.filter = CAASImageFilterCustom
+		o.hasFilter = true
+		o.custom = custom
+	}
+}
+
+// WithBlur blurs the cat server-side
+func WithBlur(blur int) Option {
+	return func(o *options) {
+		o.blur = blur
+	}
+}
+
+// catURL is the base used by the CatURL builder
+func (o *options) catURL() string {
+	return o.baseURL + o.endpoint
+}
+
+// tagsURL is the tag listing on the same instance
+func (o *options) tagsURL() string {
+	return o.baseURL + caasTagsEndpoint + caasQueryStart + caasReturnJSON
+}
+
+// requestURL is the builder for a fetch with the request options applied
+func (o *options) requestURL() *CatURL {
+	u := newCatURL(o)
+	if o.gif {
+		u = u.AsGIF()
+	}
+	if o.catID != "" {
+		u = u.WithID(o.catID)
+	}
+	if len(o.tags) > 0 {
+		u = u.WithTags(o.tags...)
+	}
+	if o.hasImgType {
+		u = u.WithCAASImageType(o.imgType)
+	}
+	if o.width > 0 {
+		u = u.WithWidth(o.width)
+	}
+	if o.height > 0 {
+		u = u.WithHeight(o.height)
+	}
+	if o.hasFilter {
+		u = u.WithCAASImageFilter(o.filter)
+		if o.filter == CAASImageFilterCustom {
+			u = o.custom.apply(u)
+		}
+	}
+	if o.blur > 0 {
+		u = u.WithBlur(o.blur)
+	}
+	if o.hasSays {
+		u = u.WithSays(o.says)
+		if o.fontSize > 0 {
+			u = u.WithFontSize(o.fontSize)
+		}
+		if o.fontColor != "" {
+			u = u.WithFontColor(o.fontColor)
+		}
+	}
+	return u
+}
+
+// apply adds the non-zero params to a builder with the custom filter set
+func (f CustomFilter) apply(u *CatURL) *CatURL {
+	if f.R != 0 || f.G != 0 || f.B != 0 {
+		u = u.WithFilterRGB(f.R, f.G, f.B)
+	}
+	if f.Brightness != 0 {
+		u = u.WithBrightness(f.Brightness)
+	}
+	if f.Saturation != 0 {
+		u = u.WithSaturation(f.Saturation)
+	}
+	if f.Hue != 0 {
+		u = u.WithHue(f.Hue)
+	}
+	if f.Lightness != 0 {
+		u = u.WithLightness(f.Lightness)
+	}
+	return u
+}
+
+// applyImageParams adds the scaling and filter params to an image URL that
+// lacks them
+func (o *options) applyImageParams(imgURL string) (string, error) {
+	u, err := url.Parse(imgURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	set := func(key, value string) {
+		if query.Get(key) == "" {
+			query.Set(key, value)
+		}
+	}
+	if o.hasImgType {
+		if str, exists := CAASImageTypes[o.imgType]; exists {
+			set(caasKeyType, str)
+		}
+	}
+	if o.width > 0 {
+		set(caasKeyWidth, strconv.Itoa(o.width))
+	}
+	if o.height > 0 {
+		set(caasKeyHeight, strconv.Itoa(o.height))
+	}
+	if o.hasFilter {
+		if str, exists := CAASImageFilters[o.filter]; exists {
+			set(caasKeyFilter, str)
+		}
+	}
+	if o.blur > 0 {
+		set(caasKeyBlur, strconv.Itoa(o.blur))
+	}
+	if len(query) == 0 {
+		return imgURL, nil
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
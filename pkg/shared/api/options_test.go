@@ -0,0 +1,306 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestNewCatURL_Options tests base URL and endpoint options on the builder
+func TestNewCatURL_Options(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "default",
+			opts:     nil,
+			expected: "https://cataas.com/cat",
+		},
+		{
+			name:     "base_url",
+			opts:     []Option{WithBaseURL("http://mirror.local:8080")},
+			expected: "http://mirror.local:8080/cat",
+		},
+		{
+			name:     "base_url_trailing_slash",
+			opts:     []Option{WithBaseURL("http://mirror.local/")},
+			expected: "http://mirror.local/cat",
+		},
+		{
+			name:     "endpoint",
+			opts:     []Option{WithBaseURL("http://mirror.local"), WithEndpoint("api/cats/")},
+			expected: "http://mirror.local/api/cats",
+		},
+		{
+			name:     "empty_endpoint",
+			opts:     []Option{WithBaseURL("http://mirror.local"), WithEndpoint("")},
+			expected: "http://mirror.local",
+		},
+		{
+			name:     "nil_option_ignored",
+			opts:     []Option{nil},
+			expected: "https://cataas.com/cat",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCatURL(tt.opts...).Generate()
+			testutil.AssertNoError(t, err, "Generate should succeed")
+			testutil.AssertEqual(t, tt.expected, got, "generated URL")
+		})
+	}
+
+	t.Run("with_id", func(t *testing.T) {
+		got, err := NewCatURL(WithBaseURL("http://mirror.local")).WithID("abc").Generate()
+		testutil.AssertNoError(t, err, "Generate should succeed")
+		testutil.AssertEqual(t, "http://mirror.local/cat/abc", got, "generated URL")
+	})
+}
+
+// TestOptions_TagsURL tests that the tag listing follows the base URL
+func TestOptions_TagsURL(t *testing.T) {
+	testutil.AssertEqual(t, "https://cataas.com/api/tags?json=true", newOptions(nil).tagsURL(), "default tags URL")
+	testutil.AssertEqual(t, "http://mirror.local/api/tags?json=true",
+		newOptions([]Option{WithBaseURL("http://mirror.local")}).tagsURL(), "mirror tags URL")
+}
+
+// TestResolveImageURL tests resolving metadata image URLs against the request
+func TestResolveImageURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		imgURL   string
+		expected string
+	}{
+		{"absolute", "https://cdn.example.com/cat.png", "https://cdn.example.com/cat.png"},
+		{"root_relative", "/cat/abc", "http://mirror.local/cat/abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveImageURL("http://mirror.local/cat?&json=true", tt.imgURL)
+			testutil.AssertNoError(t, err, "resolve should succeed")
+			testutil.AssertEqual(t, tt.expected, got, "resolved URL")
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := resolveImageURL("http://mirror.local/cat", "")
+		testutil.AssertTrue(t, errors.Is(err, ErrNoImageURL), "empty URL should fail")
+	})
+}
+
+// TestRequestRandomCat_WithBaseURL tests fetching from a mirror without touching the default transport
+func TestRequestRandomCat_WithBaseURL(t *testing.T) {
+	var paths []string
+
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/cats":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			// relative image URL, resolved against the mirror
+			w.Write([]byte(fmt.Sprintf(testutil.ValidMetadataJSON(), "/cats/test123")))
+		case "/cats/test123":
+			w.Header().Set("Content-Type", "image/png")
+			w.WriteHeader(http.StatusOK)
+			w.Write(testutil.ValidPNGBytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mirror.Close()
+
+	img, meta, err := RequestRandomCat(5*time.Second, WithBaseURL(mirror.URL), WithEndpoint("/cats"))
+	testutil.AssertNoError(t, err, "RequestRandomCat should succeed against the mirror")
+	testutil.AssertNotNil(t, img, "image")
+	testutil.AssertNotNil(t, meta, "metadata")
+	testutil.AssertEqual(t, 2, len(paths), "metadata and image requests")
+	testutil.AssertEqual(t, "/cats/test123", paths[1], "image path")
+}
+
+// TestOptions_Size tests that size options reach the request URL
+func TestOptions_Size(t *testing.T) {
+	got, err := newOptions([]Option{WithImageType(CAASImageTypeMedium), WithWidth(400), WithHeight(500)}).requestURL().AsJSON().Generate()
+	testutil.AssertNoError(t, err, "Generate should succeed")
+	testutil.AssertEqual(t, "https://cataas.com/cat?type=medium&width=400&height=500&json=true", got, "generated URL")
+}
+
+// TestOptions_ApplyImageParams tests carrying scaling params over to the image URL
+func TestOptions_ApplyImageParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		imgURL   string
+		expected string
+	}{
+		{
+			name:     "no_params",
+			opts:     nil,
+			imgURL:   "https://cataas.com/cat/abc",
+			expected: "https://cataas.com/cat/abc",
+		},
+		{
+			name:     "adds_missing",
+			opts:     []Option{WithWidth(400), WithImageType(CAASImageTypeSmall)},
+			imgURL:   "https://cataas.com/cat/abc",
+			expected: "https://cataas.com/cat/abc?type=small&width=400",
+		},
+		{
+			name:     "keeps_existing",
+			opts:     []Option{WithWidth(400)},
+			imgURL:   "https://cataas.com/cat/abc?width=200",
+			expected: "https://cataas.com/cat/abc?width=200",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newOptions(tt.opts).applyImageParams(tt.imgURL)
+			testutil.AssertNoError(t, err, "apply should succeed")
+			testutil.AssertEqual(t, tt.expected, got, "image URL")
+		})
+	}
+}
+
+// TestOptions_Filters tests that filter options reach the request URL
+func TestOptions_Filters(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "mono",
+			opts:     []Option{WithFilter(CAASImageFilterMono)},
+			expected: "https://cataas.com/cat?filter=mono",
+		},
+		{
+			name:     "blur",
+			opts:     []Option{WithBlur(8)},
+			expected: "https://cataas.com/cat?blur=8",
+		},
+		{
+			name:     "negate_and_blur",
+			opts:     []Option{WithFilter(CAASImageFilterNegate), WithBlur(2)},
+			expected: "https://cataas.com/cat?filter=negate&blur=2",
+		},
+		{
+			name:     "custom",
+			opts:     []Option{WithCustomFilter(CustomFilter{R: 255, G: 100, B: 0, Brightness: 2, Hue: 90})},
+			expected: "https://cataas.com/cat?filter=custom&r=255&g=100&b=0&brightness=2&hue=90",
+		},
+		{
+			name:     "custom_invalid_rgb_dropped",
+			opts:     []Option{WithCustomFilter(CustomFilter{R: 300, Saturation: 3})},
+			expected: "https://cataas.com/cat?filter=custom&saturation=3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newOptions(tt.opts).requestURL().Generate()
+			testutil.AssertNoError(t, err, "Generate should succeed")
+			testutil.AssertEqual(t, tt.expected, got, "generated URL")
+		})
+	}
+
+	t.Run("image_url", func(t *testing.T) {
+		got, err := newOptions([]Option{WithFilter(CAASImageFilterMono), WithBlur(4)}).applyImageParams("https://cataas.com/cat/abc")
+		testutil.AssertNoError(t, err, "apply should succeed")
+		testutil.AssertEqual(t, "https://cataas.com/cat/abc?blur=4&filter=mono", got, "image URL")
+	})
+}
+
+// TestOptions_MaxBodySize tests the default cap and ignoring non-positive sizes
+func TestOptions_MaxBodySize(t *testing.T) {
+	testutil.AssertEqual(t, int64(DefaultMaxBodySize), newOptions(nil).maxBody, "default")
+	testutil.AssertEqual(t, int64(1024), newOptions([]Option{WithMaxBodySize(1024)}).maxBody, "override")
+	testutil.AssertEqual(t, int64(DefaultMaxBodySize), newOptions([]Option{WithMaxBodySize(0)}).maxBody, "zero ignored")
+}
+
+// TestOptions_WithLogger tests logs go to the given logger at their levels
+func TestOptions_WithLogger(t *testing.T) {
+	server := catServer(t)
+
+	tests := []struct {
+		name      string
+		level     slog.Level
+		wantFetch bool
+	}{
+		{"debug", slog.LevelDebug, true},
+		{"info", slog.LevelInfo, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: tt.level}))
+
+			_, meta, err := NewClient(nil, WithBaseURL(server.URL), WithLogger(logger)).RandomCat()
+			testutil.AssertNoError(t, err, "RandomCat should succeed")
+			testutil.AssertEqual(t, tt.wantFetch, strings.Contains(buf.String(), `msg="Fetching image"`), "fetch logged")
+			if tt.wantFetch {
+				testutil.AssertTrue(t, strings.Contains(buf.String(), "request_id="+meta.GetRequestID()), "request ID logged")
+			}
+		})
+	}
+}
+
+// TestOptions_Headers tests the User-Agent and custom headers reach both
+// the metadata and image requests
+func TestOptions_Headers(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantAgent string
+		wantTrace []string
+	}{
+		{"default", nil, DefaultUserAgent, nil},
+		{"user_agent", []Option{WithUserAgent(UserAgent("v1.2.0"))}, "catfetch/1.2.0 (+https://github.com/bmj2728/catfetch)", nil},
+		{"empty_user_agent", []Option{WithUserAgent("")}, DefaultUserAgent, nil},
+		{"headers", []Option{WithHeader("x-trace", "a"), WithHeader("X-Trace", "b")}, DefaultUserAgent, []string{"a", "b"}},
+		{"header_overrides_agent", []Option{WithHeader("User-Agent", "proxy-test")}, "proxy-test", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var got []http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				got = append(got, r.Header.Clone())
+				mu.Unlock()
+				if r.URL.Path == "/image" {
+					w.Write(testutil.ValidPNGBytes())
+					return
+				}
+				w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+			}))
+			defer server.Close()
+
+			opts := append([]Option{WithBaseURL(server.URL), WithHeader(RequestIDHeader, "spoofed")}, tt.opts...)
+			_, meta, err := NewClient(nil, opts...).RandomCat()
+			testutil.AssertNoError(t, err, "RandomCat should succeed")
+
+			testutil.AssertEqual(t, 2, len(got), "metadata and image requests")
+			for _, h := range got {
+				testutil.AssertEqual(t, tt.wantAgent, h.Get("User-Agent"), "user agent")
+				testutil.AssertEqual(t, fmt.Sprint(tt.wantTrace), fmt.Sprint(h.Values("X-Trace")), "custom header")
+				testutil.AssertEqual(t, meta.GetRequestID(), h.Get(RequestIDHeader), "request ID not overridden")
+			}
+		})
+	}
+}
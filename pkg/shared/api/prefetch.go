@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// prefetchPause is how long the prefetcher waits after failures, doubling
+// per consecutive failure. It stays low on requests while offline or rate
+// limited, and resumes on the first success.
+var prefetchPause = RetryPolicy{
+	BaseDelay: 2 * time.Second,
+	MaxDelay:  time.Minute,
+}
+
+// Prefetcher keeps random cats fetched and decoded in the background so the
+// next one is ready at once. Each fetch goes through the provider and so
+// through its retry policy, and the prefetcher pauses with growing delays
+// while fetches keep failing.
+type Prefetcher struct {
+	provider CatProvider
+	opts     []Option
+	ready    chan *FetchResult
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewPrefetcher starts keeping n cats ready, fetched from provider with
+// opts, until Close is called. With n < 1 nothing is prefetched and Next
+// fetches on demand.
+func NewPrefetcher(provider CatProvider, n int, opts ...Option) *Prefetcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Prefetcher{
+		provider: provider,
+		opts:     opts,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	if n < 1 {
+		close(p.done)
+		return p
+	}
+	// the worker holds one more while it waits to hand it over
+	p.ready = make(chan *FetchResult, n-1)
+	go p.run(ctx)
+	return p
+}
+
+// Next returns a prefetched cat, or fetches one now when none is ready
+func (p *Prefetcher) Next(ctx context.Context) (*FetchResult, error) {
+	select {
+	case res := <-p.ready:
+		return res, nil
+	default:
+	}
+	return p.provider.FetchRandom(ctx, p.opts...)
+}
+
+// Close cancels any fetch in flight and waits for the worker to stop. Next
+// keeps working afterwards, fetching on demand.
+func (p *Prefetcher) Close() {
+	p.cancel()
+	<-p.done
+}
+
+func (p *Prefetcher) run(ctx context.Context) {
+	defer close(p.done)
+
+	o := newOptions(p.opts)
+	failures := 0
+	for {
+		res, err := p.provider.FetchRandom(ctx, p.opts...)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			failures++
+			delay := prefetchPause.backoff(failures, o)
+			// wait at least as long as the provider asked
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				delay = max(delay, statusErr.RetryAfter)
+			}
+			o.log().Info("Prefetch failed, pausing", "delay", delay, "err", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-o.after(delay):
+			}
+			continue
+		}
+		failures = 0
+
+		select {
+		case p.ready <- res:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// countingProvider numbers the cats it returns, failing the first fails calls
+type countingProvider struct {
+	calls atomic.Int32
+	fails int32
+}
+
+func (p *countingProvider) FetchRandom(ctx context.Context, opts ...Option) (*FetchResult, error) {
+	n := p.calls.Add(1)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if n <= p.fails {
+		return nil, errors.New("offline")
+	}
+	return &FetchResult{
+		Image:    testutil.CreateColorImage(1, 1, 0, 0, 0),
+		Metadata: &CatMetadata{ID: string(rune('a' + n - 1))},
+	}, nil
+}
+
+func (p *countingProvider) FetchByID(ctx context.Context, id string, opts ...Option) (*FetchResult, error) {
+	return p.FetchRandom(ctx, opts...)
+}
+
+func (p *countingProvider) ListTags(ctx context.Context, opts ...Option) (CAASTags, error) {
+	return nil, nil
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestPrefetcher tests cats are kept ready and refilled
+func TestPrefetcher(t *testing.T) {
+	provider := &countingProvider{}
+	p := NewPrefetcher(provider, 2)
+	defer p.Close()
+
+	waitFor(t, func() bool { return provider.calls.Load() == 2 }, "should prefetch two cats")
+	time.Sleep(20 * time.Millisecond)
+	testutil.AssertEqual(t, int32(2), provider.calls.Load(), "should stop at two")
+
+	res, err := p.Next(context.Background())
+	testutil.AssertNoError(t, err, "Next should succeed")
+	testutil.AssertEqual(t, "a", res.Metadata.GetID(), "oldest prefetched cat first")
+	waitFor(t, func() bool { return provider.calls.Load() == 3 }, "should refill")
+}
+
+// TestPrefetcher_None tests n < 1 prefetches nothing and Next fetches on demand
+func TestPrefetcher_None(t *testing.T) {
+	provider := &countingProvider{}
+	p := NewPrefetcher(provider, 0)
+	defer p.Close()
+
+	time.Sleep(20 * time.Millisecond)
+	testutil.AssertEqual(t, int32(0), provider.calls.Load(), "should not prefetch")
+
+	res, err := p.Next(context.Background())
+	testutil.AssertNoError(t, err, "Next should succeed")
+	testutil.AssertEqual(t, "a", res.Metadata.GetID(), "Next should fetch")
+	time.Sleep(20 * time.Millisecond)
+	testutil.AssertEqual(t, int32(1), provider.calls.Load(), "should not refill")
+}
+
+// TestPrefetcher_Close tests closing stops the worker and Next falls back
+func TestPrefetcher_Close(t *testing.T) {
+	provider := &countingProvider{}
+	p := NewPrefetcher(provider, 1)
+	waitFor(t, func() bool { return provider.calls.Load() == 1 }, "should prefetch a cat")
+	p.Close()
+	p.Close()
+
+	calls := provider.calls.Load()
+	_, err := p.Next(context.Background())
+	testutil.AssertNoError(t, err, "Next should succeed after Close")
+	_, err = p.Next(context.Background())
+	testutil.AssertNoError(t, err, "Next should fetch on demand")
+	testutil.AssertTrue(t, provider.calls.Load() > calls, "fetched on demand")
+}
+
+// TestPrefetcher_Pause tests failures pause the worker until a fetch succeeds
+func TestPrefetcher_Pause(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)}
+	provider := &countingProvider{fails: 3}
+	p := NewPrefetcher(provider, 1, WithClock(clock))
+	defer p.Close()
+
+	waitFor(t, func() bool { return provider.calls.Load() == 4 }, "should recover after failures")
+	res, err := p.Next(context.Background())
+	testutil.AssertNoError(t, err, "Next should succeed")
+	testutil.AssertEqual(t, "d", res.Metadata.GetID(), "first successful cat")
+
+	waits := clock.waited()
+	testutil.AssertEqual(t, 3, len(waits), "paused after each failure")
+	testutil.AssertTrue(t, waits[0] >= prefetchPause.BaseDelay/2, "paused on the clock for the backoff")
+}
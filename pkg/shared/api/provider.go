@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoID = errors.New("no cat id given")
+
+// CatProvider is a source of cats. The UI depends on this rather than on the
+// cataas functions so other sources can be plugged in. Options a provider
+// doesn't support are ignored.
+type CatProvider interface {
+	FetchRandom(ctx context.Context, opts ...Option) (*FetchResult, error)
+	FetchByID(ctx context.Context, id string, opts ...Option) (*FetchResult, error)
+	ListTags(ctx context.Context, opts ...Option) (CAASTags, error)
+}
+
+// Client is the cataas provider
+var _ CatProvider = (*Client)(nil)
+
+// DefaultProvider returns the cataas provider used by the package level
+// functions
+func DefaultProvider() CatProvider {
+	return defaultClient
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestClient_FetchByID tests fetching a specific cat
+func TestClient_FetchByID(t *testing.T) {
+	var metaPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(testutil.ValidPNGBytes())
+			return
+		}
+		metaPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+	}))
+	defer server.Close()
+
+	var p CatProvider = NewClient(nil, WithBaseURL(server.URL))
+
+	t.Run("by_id", func(t *testing.T) {
+		res, err := p.FetchByID(context.Background(), "abc123")
+		testutil.AssertNoError(t, err, "FetchByID should succeed")
+		testutil.AssertNotNil(t, res.Image, "image")
+		testutil.AssertEqual(t, "png", res.Format, "detected format")
+		testutil.AssertEqual(t, testutil.ValidPNGBytes(), res.Bytes, "raw bytes")
+		testutil.AssertEqual(t, len(res.Bytes), res.Metadata.GetSize(), "size")
+		testutil.AssertEqual(t, "/cat/abc123", metaPath, "metadata path")
+	})
+
+	t.Run("empty_id", func(t *testing.T) {
+		_, err := p.FetchByID(context.Background(), "")
+		testutil.AssertTrue(t, errors.Is(err, ErrNoID), "empty ID should be rejected")
+	})
+
+	t.Run("id_and_tag", func(t *testing.T) {
+		_, err := p.FetchByID(context.Background(), "abc123", WithTags("cute"))
+		testutil.AssertTrue(t, errors.Is(err, ErrIDAndTag), "ID and tags should be rejected")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := p.FetchRandom(ctx)
+		testutil.AssertTrue(t, errors.Is(err, context.Canceled), "cancelled fetch should fail")
+	})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var ErrProxyURL = errors.New("invalid proxy url")
+
+// NewTransport returns a transport sending requests through proxyURL, e.g.
+// http://localhost:8080 or socks5://localhost:1080. An empty proxyURL uses
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment, as
+// http.DefaultTransport does. Wrap it in an http.Client, or a DiskCache, and
+// pass that to NewClient or NewTheCatAPI. Image downloads through it only
+// connect to public addresses of allowed hosts, see guardDial.
+func NewTransport(proxyURL string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	guardTransport(transport)
+	if proxyURL == "" {
+		return transport, nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProxyURL, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrProxyURL, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%w: no host in %q", ErrProxyURL, proxyURL)
+	}
+	transport.Proxy = http.ProxyURL(u)
+	return transport, nil
+}
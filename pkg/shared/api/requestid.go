@@ -0,0 +1,47 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// RequestIDHeader is sent with every outgoing request so a fetch can be
+	// correlated with server-side and proxy logs
+	RequestIDHeader = "X-Request-ID"
+
+	requestIDBytes = 8
+)
+
+// RequestError wraps an error from a fetch with the ID of the request it occurred in
+type RequestError struct {
+	RequestID string
+	Err       error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("request %s: %v", e.RequestID, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// newRequestID generates a short random hex ID for a single fetch
+func newRequestID() string {
+	b := make([]byte, requestIDBytes)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand failing is exceptional, fall back to a fixed marker
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// wrapRequestError attaches the request ID to err, leaving nil untouched
+func wrapRequestError(requestID string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RequestError{RequestID: requestID, Err: err}
+}
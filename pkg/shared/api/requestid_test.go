@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestNewRequestID tests request ID generation
+func TestNewRequestID(t *testing.T) {
+	id := newRequestID()
+	testutil.AssertEqual(t, requestIDBytes*2, len(id), "request ID length")
+	testutil.AssertNotEqual(t, id, newRequestID(), "request IDs should be unique")
+}
+
+// TestRequestError tests wrapping and unwrapping of request errors
+func TestRequestError(t *testing.T) {
+	t.Run("nil_error_stays_nil", func(t *testing.T) {
+		testutil.AssertNil(t, wrapRequestError("abc", nil), "nil error should not be wrapped")
+	})
+
+	t.Run("wraps_with_id", func(t *testing.T) {
+		base := errors.New("boom")
+		err := wrapRequestError("abc123", base)
+
+		testutil.AssertContains(t, err.Error(), "abc123", "error should mention request ID")
+		testutil.AssertContains(t, err.Error(), "boom", "error should mention cause")
+		testutil.AssertTrue(t, errors.Is(err, base), "wrapped error should unwrap to cause")
+
+		var reqErr *RequestError
+		testutil.AssertTrue(t, errors.As(err, &reqErr), "should be a RequestError")
+		testutil.AssertEqual(t, "abc123", reqErr.RequestID, "request ID")
+	})
+}
+
+// TestRequestRandomCat_RequestIDPropagation tests that one ID is sent on every request and returned
+func TestRequestRandomCat_RequestIDPropagation(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get(RequestIDHeader))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		w.Write(testutil.ValidPNGBytes())
+	}))
+	defer imageServer.Close()
+
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get(RequestIDHeader))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fmt.Sprintf(testutil.ValidMetadataJSON(), imageServer.URL)))
+	}))
+	defer metadataServer.Close()
+
+	oldTransport := http.DefaultTransport
+	http.DefaultTransport = &redirectTransport{
+		metadataURL:   metadataServer.URL,
+		realTransport: http.DefaultTransport,
+	}
+	defer func() { http.DefaultTransport = oldTransport }()
+
+	_, meta, err := RequestRandomCat(5*time.Second, allowServer(imageServer.URL))
+	testutil.AssertNoError(t, err, "RequestRandomCat should succeed")
+
+	testutil.AssertEqual(t, 2, len(seen), "metadata and image requests")
+	testutil.AssertTrue(t, seen[0] != "", "request ID header should be set")
+	testutil.AssertEqual(t, seen[0], seen[1], "both requests should share the ID")
+	testutil.AssertEqual(t, seen[0], meta.GetRequestID(), "metadata should carry the ID")
+}
+
+// TestRequestRandomCat_RequestIDOnError tests that failures carry the request ID
+func TestRequestRandomCat_RequestIDOnError(t *testing.T) {
+	var sentID string
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sentID = r.Header.Get(RequestIDHeader)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(testutil.MalformedMetadataJSON()))
+	}))
+	defer metadataServer.Close()
+
+	oldTransport := http.DefaultTransport
+	http.DefaultTransport = &redirectTransport{
+		metadataURL:   metadataServer.URL,
+		realTransport: http.DefaultTransport,
+	}
+	defer func() { http.DefaultTransport = oldTransport }()
+
+	_, _, err := RequestRandomCat(5 * time.Second)
+	testutil.AssertError(t, err, "should fail with malformed JSON")
+
+	var reqErr *RequestError
+	testutil.AssertTrue(t, errors.As(err, &reqErr), "error should be a RequestError")
+	testutil.AssertEqual(t, sentID, reqErr.RequestID, "error should carry the sent ID")
+}
@@ -0,0 +1,29 @@
+package api
+
+import "image"
+
+// FetchResult is a fetched cat, with the image both as downloaded and as
+// decoded so it can be stored without encoding it again
+type FetchResult struct {
+	Image    image.Image
+	Bytes    []byte // the image as served
+	Format   string // as detected when decoding, such as "jpeg" or "gif"
+	Metadata *CatMetadata
+	Source   string          // the provider that served the cat, such as SourceCataas
+	Timings  []RequestTiming // of each request made, with WithTracing
+}
+
+// Sources of the built-in providers
+const (
+	SourceCataas    = "cataas"
+	SourceTheCatAPI = "thecatapi"
+)
+
+// unpack splits a result for the functions returning only the image and
+// its metadata
+func unpack(res *FetchResult, err error) (image.Image, *CatMetadata, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Image, res.Metadata, nil
+}
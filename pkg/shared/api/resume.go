@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// readImage reads the image body of resp, resuming with a Range request
+// when the connection drops partway through instead of starting over.
+// Resuming needs the server to accept byte ranges and to name the image
+// with an ETag or Last-Modified, which If-Range sends back so a changed
+// image is served whole. Each resume is another attempt of the retry
+// policy, and timeouts aren't resumed for the same reason they aren't
+// retried. The bodies are closed.
+func (c *Client) readImage(req *http.Request, resp *http.Response, o *options) ([]byte, error) {
+	requestID := req.Header.Get(RequestIDHeader)
+	validator := rangeValidator(resp)
+
+	var buf bytes.Buffer
+	for resumes := 1; ; resumes++ {
+		_, err := io.Copy(&buf, io.LimitReader(resp.Body, o.maxBody+1-int64(buf.Len())))
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			o.log().Warn("Error closing image response", "request_id", requestID, "err", closeErr)
+		}
+		if int64(buf.Len()) > o.maxBody {
+			return nil, fmt.Errorf("%w: body exceeds %d bytes", ErrImageTooLarge, o.maxBody)
+		}
+		if err == nil {
+			return buf.Bytes(), nil
+		}
+		if validator == "" || resumes >= o.retry.MaxAttempts || isTimeout(err) || req.Context().Err() != nil {
+			return nil, transportError(err)
+		}
+
+		offset := buf.Len()
+		o.log().Info("Resuming download", "request_id", requestID, "offset", offset, "err", err)
+		resp, err = c.resume(req, o, offset, validator)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusPartialContent {
+			// the image changed, so the server sent all of it
+			buf.Reset()
+		}
+	}
+}
+
+// resume requests the rest of the image from offset on
+func (c *Client) resume(req *http.Request, o *options, offset int, validator string) (*http.Response, error) {
+	rangeReq := req.Clone(req.Context())
+	rangeReq.Header.Set("Range", "bytes="+strconv.Itoa(offset)+"-")
+	rangeReq.Header.Set("If-Range", validator)
+
+	resp, err := o.retry.do(c.imageClientFor(o), rangeReq, o)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusPartialContent && !strings.HasPrefix(resp.Header.Get("Content-Range"), "bytes "+strconv.Itoa(offset)+"-") {
+		_ = resp.Body.Close()
+		return nil, wrapError(ErrNetwork, fmt.Errorf("resuming at %d: unexpected content range %q", offset, resp.Header.Get("Content-Range")))
+	}
+	return resp, nil
+}
+
+// rangeValidator returns the ETag or Last-Modified to resume resp with, or
+// "" when it can't be resumed. Weak ETags don't promise identical bytes,
+// and a body decompressed by the transport has offsets that don't match
+// the server's.
+func rangeValidator(resp *http.Response) string {
+	if resp.Header.Get("Accept-Ranges") != "bytes" || resp.Uncompressed {
+		return ""
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
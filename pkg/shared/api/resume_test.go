@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// resumeServer serves an image whose first download drops halfway. The
+// image has an ETag when etag isn't empty, which changes to changedETag
+// after the first download. It returns the Range and If-Range headers of
+// each image request.
+func resumeServer(t *testing.T, data []byte, etag, changedETag string) (*httptest.Server, func() [][2]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests [][2]string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/image" {
+			w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+			return
+		}
+		mu.Lock()
+		requests = append(requests, [2]string{r.Header.Get("Range"), r.Header.Get("If-Range")})
+		first := len(requests) == 1
+		mu.Unlock()
+
+		if first {
+			if etag != "" {
+				w.Header().Set("ETag", etag)
+				w.Header().Set("Accept-Ranges", "bytes")
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(data[:len(data)/2])
+			w.(http.Flusher).Flush()
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		if changedETag != "" {
+			w.Header().Set("ETag", changedETag)
+		} else if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() [][2]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+// TestReadImage_Resume tests a dropped download carries on where it stopped
+func TestReadImage_Resume(t *testing.T) {
+	data, err := testutil.CreateTestImageBytes(64, 64, "png")
+	testutil.AssertNoError(t, err, "encoding image")
+
+	tests := []struct {
+		name        string
+		changedETag string
+		wantRange   string
+	}{
+		{"resumed", "", "bytes=" + strconv.Itoa(len(data)/2) + "-"},
+		{"image_changed", `"v2"`, "bytes=" + strconv.Itoa(len(data)/2) + "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := resumeServer(t, data, `"v1"`, tt.changedETag)
+
+			res, err := NewClient(nil, WithBaseURL(server.URL)).FetchRandom(t.Context())
+			testutil.AssertNoError(t, err, "FetchRandom should succeed")
+			testutil.AssertTrue(t, bytes.Equal(data, res.Bytes), "whole image")
+			testutil.AssertImageDimensions(t, res.Image, 64, 64)
+
+			got := requests()
+			testutil.AssertEqual(t, 2, len(got), "image requests")
+			testutil.AssertEqual(t, "", got[0][0], "first request not ranged")
+			testutil.AssertEqual(t, tt.wantRange, got[1][0], "resumed from the break")
+			testutil.AssertEqual(t, `"v1"`, got[1][1], "If-Range")
+		})
+	}
+}
+
+// TestReadImage_NotResumable tests downloads fail as before when they
+// can't be resumed
+func TestReadImage_NotResumable(t *testing.T) {
+	data, err := testutil.CreateTestImageBytes(64, 64, "png")
+	testutil.AssertNoError(t, err, "encoding image")
+
+	tests := []struct {
+		name string
+		etag string
+		opts []Option
+	}{
+		{"no_validator", "", nil},
+		{"no_retries", `"v1"`, []Option{WithoutRetries()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := resumeServer(t, data, tt.etag, "")
+
+			opts := append([]Option{WithBaseURL(server.URL)}, tt.opts...)
+			_, err := NewClient(nil, opts...).FetchRandom(t.Context())
+			testutil.AssertTrue(t, errors.Is(err, ErrNetwork), "should be a network error, got "+errString(err))
+			testutil.AssertEqual(t, 1, len(requests()), "not resumed")
+		})
+	}
+}
+
+// TestRangeValidator tests which responses can be resumed
+func TestRangeValidator(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"etag", http.Header{"Accept-Ranges": {"bytes"}, "Etag": {`"abc"`}}, `"abc"`},
+		{"last_modified", http.Header{"Accept-Ranges": {"bytes"}, "Last-Modified": {"Wed, 01 May 2024 12:00:00 GMT"}}, "Wed, 01 May 2024 12:00:00 GMT"},
+		{"weak_etag", http.Header{"Accept-Ranges": {"bytes"}, "Etag": {`W/"abc"`}}, ""},
+		{"no_ranges", http.Header{"Etag": {`"abc"`}}, ""},
+		{"ranges_none", http.Header{"Accept-Ranges": {"none"}, "Etag": {`"abc"`}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, rangeValidator(&http.Response{Header: tt.header}), "validator")
+		})
+	}
+
+	t.Run("decompressed", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{"Accept-Ranges": {"bytes"}, "Etag": {`"abc"`}}, Uncompressed: true}
+		testutil.AssertEqual(t, "", rangeValidator(resp), "validator")
+	})
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError is returned when the server answers with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // how long the server asked us to wait, 0 if it didn't
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %s", e.RetryAfter)
+	}
+	return msg
+}
+
+// RetryPolicy controls how failed requests are retried. Connection errors
+// and the retryable status codes are retried with exponential backoff and
+// jitter. A timeout isn't retried since the attempt used the whole budget,
+// and a cancelled context stops retrying. A Retry-After header replaces the
+// backoff, unless it asks for longer than MaxDelay, in which case the
+// *StatusError is returned at once carrying the wait.
+type RetryPolicy struct {
+	MaxAttempts     int           // attempts including the first, 1 disables retries
+	BaseDelay       time.Duration // delay before the first retry, doubled after each
+	MaxDelay        time.Duration // cap on a single delay
+	RetryableStatus []int
+}
+
+// DefaultRetryPolicy is used unless WithRetryPolicy or WithoutRetries is given
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	RetryableStatus: []int{
+		http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// WithRetryPolicy replaces the default retry policy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+// WithoutRetries makes every request a single attempt, mostly for tests
+func WithoutRetries() Option {
+	return func(o *options) {
+		o.retry = RetryPolicy{MaxAttempts: 1}
+	}
+}
+
+// do sends the request until it gets a 2xx response, a non-retryable
+// failure, or runs out of attempts. Each attempt is bounded by the phase
+// timeouts of o.
+func (p RetryPolicy) do(client *http.Client, req *http.Request, o *options) (*http.Response, error) {
+	logger := o.log()
+	attempts := max(p.MaxAttempts, 1)
+	requestID := req.Header.Get(RequestIDHeader)
+
+	var lastErr error
+	var wait time.Duration // asked for by the server
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			delay := wait
+			if delay == 0 {
+				delay = p.backoff(attempt-1, o)
+			}
+			logger.Info("Retrying", "request_id", requestID, "delay", delay, "attempt", attempt, "attempts", attempts, "err", lastErr)
+			select {
+			case <-req.Context().Done():
+				return nil, transportError(req.Context().Err())
+			case <-o.after(delay):
+			}
+		}
+
+		attemptReq, watch := o.timeouts.watch(req)
+		var tracer *requestTracer
+		if o.timings != nil {
+			attemptReq, tracer = o.timings.trace(attemptReq, attempt, o.now)
+		}
+		record := func(err error) {
+			if tracer != nil {
+				timing := tracer.finish(err)
+				o.timings.add(timing)
+				logger.Debug("Request timing", "request_id", requestID, "timing", timing)
+			}
+		}
+
+		start := o.now()
+		resp, err := client.Do(attemptReq)
+		apiMetrics.observeRequest(o.now().Sub(start))
+		if err != nil {
+			err = watch.err(err)
+			watch.done()
+			record(err)
+			wait = 0
+			// a cancelled request or a timeout won't do better next time
+			if isTimeout(err) || req.Context().Err() != nil {
+				return nil, transportError(err)
+			}
+			// nor will a redirect or an address we refuse to follow
+			if errors.Is(err, ErrUnsafeImageURL) {
+				return nil, err
+			}
+			lastErr = transportError(err)
+			continue
+		}
+		resp.Body = watch.body(resp.Body, o.timeouts.Body)
+		if tracer != nil {
+			var statusErr error
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				statusErr = &StatusError{StatusCode: resp.StatusCode}
+			}
+			resp.Body = &onClose{ReadCloser: resp.Body, fn: func() { record(statusErr) }}
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			resp.Body = countingBody{resp.Body}
+			return resp, nil
+		}
+
+		// drain a little so the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+
+		wait = retryAfter(resp.Header, o.now())
+		lastErr = &StatusError{StatusCode: resp.StatusCode, RetryAfter: wait}
+		if !slices.Contains(p.RetryableStatus, resp.StatusCode) {
+			return nil, lastErr
+		}
+		// rather than hold the fetch for long, let the caller wait
+		if p.MaxDelay > 0 && wait > p.MaxDelay {
+			return nil, lastErr
+		}
+	}
+	return nil, lastErr
+}
+
+// backoff is the delay before the nth retry, BaseDelay doubled n-1 times and
+// capped at MaxDelay, with the upper half randomized by the jitter of o
+func (p RetryPolicy) backoff(n int, o *options) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay << (n - 1)
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay <= 0) {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	return half + o.jitter(half+1)
+}
+
+// retryAfter parses the Retry-After header, given either in seconds or as
+// an HTTP date, returning 0 when it is missing, invalid or in the past
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// isTimeout reports whether err is a timeout anywhere in its chain
+func isTimeout(err error) bool {
+	var te interface{ Timeout() bool }
+	return errors.As(err, &te) && te.Timeout()
+}
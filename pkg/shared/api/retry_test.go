@@ -0,0 +1,191 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// fastRetries retries quickly so tests don't sleep
+var fastRetries = RetryPolicy{
+	MaxAttempts:     3,
+	BaseDelay:       time.Millisecond,
+	MaxDelay:        5 * time.Millisecond,
+	RetryableStatus: DefaultRetryPolicy.RetryableStatus,
+}
+
+// flakyServer fails the first n metadata requests with status, then serves a cat
+func flakyServer(t *testing.T, n int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(testutil.ValidPNGBytes())
+			return
+		}
+		if calls.Add(1) <= n {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// TestRetryPolicy_Backoff tests delays grow, stay capped, and keep jitter in range
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	tests := []struct {
+		n        int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 150 * time.Millisecond, 300 * time.Millisecond},
+		{10, 150 * time.Millisecond, 300 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			d := p.backoff(tt.n, newOptions(nil))
+			testutil.AssertTrue(t, d >= tt.min && d <= tt.max, "backoff within bounds")
+		}
+	}
+
+	testutil.AssertEqual(t, time.Duration(0), RetryPolicy{}.backoff(1, newOptions(nil)), "no base delay")
+}
+
+// TestRequestRandomCat_Retry tests retrying transient failures
+func TestRequestRandomCat_Retry(t *testing.T) {
+	t.Run("recovers_from_503", func(t *testing.T) {
+		server, calls := flakyServer(t, 2, http.StatusServiceUnavailable)
+		img, _, err := RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+		testutil.AssertNoError(t, err, "should succeed on the third attempt")
+		testutil.AssertNotNil(t, img, "image")
+		testutil.AssertEqual(t, int32(3), calls.Load(), "metadata attempts")
+	})
+
+	t.Run("gives_up_after_max_attempts", func(t *testing.T) {
+		server, calls := flakyServer(t, 5, http.StatusBadGateway)
+		_, _, err := RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+		var statusErr *StatusError
+		testutil.AssertTrue(t, errors.As(err, &statusErr), "should be a StatusError")
+		testutil.AssertEqual(t, http.StatusBadGateway, statusErr.StatusCode, "status code")
+		testutil.AssertEqual(t, int32(3), calls.Load(), "metadata attempts")
+	})
+
+	t.Run("does_not_retry_404", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusNotFound)
+		_, _, err := RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+		testutil.AssertError(t, err, "404 should fail")
+		testutil.AssertEqual(t, int32(1), calls.Load(), "metadata attempts")
+	})
+
+	t.Run("without_retries", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusServiceUnavailable)
+		_, _, err := RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithoutRetries())
+		testutil.AssertError(t, err, "should fail without retrying")
+		testutil.AssertEqual(t, int32(1), calls.Load(), "metadata attempts")
+	})
+
+	t.Run("does_not_retry_timeout", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		_, _, err := RequestRandomCat(50*time.Millisecond, WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+		testutil.AssertTrue(t, isTimeout(err), "should time out")
+		testutil.AssertEqual(t, int32(1), calls.Load(), "attempts")
+	})
+}
+
+// TestRetryAfter tests parsing the Retry-After header
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "missing", value: "", want: 0},
+		{name: "seconds", value: "42", want: 42 * time.Second},
+		{name: "negative", value: "-5", want: 0},
+		{name: "date", value: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second},
+		{name: "past_date", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "garbage", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.value != "" {
+				h.Set("Retry-After", tt.value)
+			}
+			testutil.AssertEqual(t, tt.want, retryAfter(h, now), "wait")
+		})
+	}
+}
+
+// TestRequestRandomCat_RetryAfter tests honouring a server asking us to wait
+func TestRequestRandomCat_RetryAfter(t *testing.T) {
+	// limitedServer answers the first metadata request with 429 and
+	// Retry-After set to wait, then serves a cat
+	limitedServer := func(t *testing.T, wait string) (*httptest.Server, *atomic.Int32) {
+		t.Helper()
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/image" {
+				w.Header().Set("Content-Type", "image/png")
+				w.Write(testutil.ValidPNGBytes())
+				return
+			}
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", wait)
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+		}))
+		t.Cleanup(server.Close)
+		return server, &calls
+	}
+
+	t.Run("short_wait_is_retried", func(t *testing.T) {
+		// the date is whole seconds, so the clock stands just before it
+		date := time.Now().Add(time.Hour).Truncate(time.Second)
+		server, calls := limitedServer(t, date.Format(http.TimeFormat))
+		clock := &fakeClock{t: date.Add(-30 * time.Millisecond)}
+
+		policy := fastRetries
+		policy.MaxDelay = time.Second
+
+		_, _, err := RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithRetryPolicy(policy), WithClock(clock))
+		testutil.AssertNoError(t, err, "should succeed after waiting")
+		testutil.AssertEqual(t, int32(2), calls.Load(), "metadata attempts")
+		testutil.AssertEqual(t, []time.Duration{30 * time.Millisecond}, clock.waited(), "should wait as asked rather than back off")
+	})
+
+	t.Run("long_wait_is_returned", func(t *testing.T) {
+		server, calls := limitedServer(t, "120")
+		_, _, err := RequestRandomCat(5*time.Second, WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+		var statusErr *StatusError
+		testutil.AssertTrue(t, errors.As(err, &statusErr), "should be a StatusError")
+		testutil.AssertEqual(t, 2*time.Minute, statusErr.RetryAfter, "wait")
+		testutil.AssertEqual(t, int32(1), calls.Load(), "should not retry before the wait is over")
+		testutil.AssertContains(t, err.Error(), "retry after 2m0s", "error message")
+	})
+}
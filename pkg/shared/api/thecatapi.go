@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	theCatAPIHost       = "https://api.thecatapi.com"
+	theCatAPISearchPath = "/v1/images/search"
+	theCatAPIImagesPath = "/v1/images/"
+	theCatAPIBreedsPath = "/v1/breeds"
+	theCatAPIKeyHeader  = "x-api-key"
+	theCatAPIImageHost  = "cdn2.thecatapi.com"
+)
+
+var ErrNoCat = errors.New("no cat returned")
+
+// TheCatAPI is a CatProvider backed by thecatapi.com, which serves larger
+// images than cataas along with breed information. Tags are breed IDs such
+// as "beng", as listed by ListTags. The API key is optional but raises the
+// rate limits.
+type TheCatAPI struct {
+	client *Client
+	apiKey string
+}
+
+var _ CatProvider = (*TheCatAPI)(nil)
+
+// theCatAPIImage is an entry of the images endpoints
+type theCatAPIImage struct {
+	ID         string  `json:"id"`
+	URL        string  `json:"url"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Breeds     []Breed `json:"breeds"`
+	Categories []struct {
+		Name string `json:"name"`
+	} `json:"categories"`
+}
+
+// NewTheCatAPI returns the provider using httpClient, which may be nil.
+// WithBaseURL points it at another instance, whose image host may need
+// WithAllowedHosts.
+func NewTheCatAPI(apiKey string, httpClient *http.Client, opts ...Option) *TheCatAPI {
+	defaults := []Option{WithBaseURL(theCatAPIHost), WithAllowedHosts(theCatAPIImageHost)}
+	return &TheCatAPI{
+		client: NewClient(httpClient, append(defaults, opts...)...),
+		apiKey: apiKey,
+	}
+}
+
+// FetchRandom fetches a random cat that has breed information, limited to
+// the breeds given with WithTags and to GIFs with WithGIF
+func (p *TheCatAPI) FetchRandom(ctx context.Context, opts ...Option) (*FetchResult, error) {
+	o := p.client.options(opts)
+
+	query := url.Values{}
+	query.Set("limit", "1")
+	query.Set("size", "full")
+	query.Set("has_breeds", "1")
+	if len(o.tags) > 0 {
+		query.Set("breed_ids", strings.Join(o.tags, caasTagSeparator))
+	}
+	if o.gif {
+		query.Set("mime_types", "gif")
+	}
+	reqURL := o.baseURL + theCatAPISearchPath + caasQueryStart + query.Encode()
+
+	return traced(o, func(requestID string) (*FetchResult, error) {
+		var results []theCatAPIImage
+		err := p.client.getJSON(ctx, requestID, o, reqURL, p.header(), &results)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) == 0 {
+			return nil, ErrNoCat
+		}
+		return p.fetchImage(ctx, requestID, o, results[0])
+	})
+}
+
+// FetchByID fetches the cat with the given image ID
+func (p *TheCatAPI) FetchByID(ctx context.Context, id string, opts ...Option) (*FetchResult, error) {
+	if id == "" {
+		return nil, ErrNoID
+	}
+	o := p.client.options(opts)
+	reqURL := o.baseURL + theCatAPIImagesPath + url.PathEscape(id)
+
+	return traced(o, func(requestID string) (*FetchResult, error) {
+		var result theCatAPIImage
+		err := p.client.getJSON(ctx, requestID, o, reqURL, p.header(), &result)
+		if err != nil {
+			return nil, err
+		}
+		return p.fetchImage(ctx, requestID, o, result)
+	})
+}
+
+// ListTags returns the breed IDs, fetching them at most once per TTL
+func (p *TheCatAPI) ListTags(ctx context.Context, opts ...Option) (CAASTags, error) {
+	o := p.client.options(opts)
+	reqURL := o.baseURL + theCatAPIBreedsPath
+
+	return p.client.tags.get(reqURL, o, func() (CAASTags, error) {
+		var breeds []Breed
+		err := p.client.getJSON(ctx, newRequestID(), o, reqURL, p.header(), &breeds)
+		if err != nil {
+			return nil, err
+		}
+		tags := make(CAASTags, 0, len(breeds))
+		for _, b := range breeds {
+			tags = append(tags, b.ID)
+		}
+		return tags, nil
+	})
+}
+
+// header carries the API key when one is set
+func (p *TheCatAPI) header() http.Header {
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set(theCatAPIKeyHeader, p.apiKey)
+	}
+	return header
+}
+
+func (p *TheCatAPI) fetchImage(ctx context.Context, requestID string, o *options, result theCatAPIImage) (*FetchResult, error) {
+	meta := result.metadata()
+	if meta.URL == "" {
+		return nil, ErrNoImageURL
+	}
+	res, err := p.client.fetchImage(ctx, requestID, o, meta.URL, meta)
+	if err != nil {
+		return nil, err
+	}
+	res.Source = SourceTheCatAPI
+	return res, nil
+}
+
+// metadata maps the entry onto CatMetadata. The API has no MIME type field,
+// so it is guessed from the file extension.
+func (r theCatAPIImage) metadata() *CatMetadata {
+	meta := &CatMetadata{
+		ID:     r.ID,
+		URL:    r.URL,
+		Breeds: r.Breeds,
+		Tags:   make([]string, 0, len(r.Categories)),
+	}
+	for _, c := range r.Categories {
+		meta.Tags = append(meta.Tags, c.Name)
+	}
+	if u, err := url.Parse(r.URL); err == nil {
+		meta.MIMEType = mime.TypeByExtension(path.Ext(u.Path))
+	}
+	return meta
+}
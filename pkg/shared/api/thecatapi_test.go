@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+const theCatAPIBengal = `{"id":"beng","name":"Bengal","origin":"United States","temperament":"Alert, Agile","life_span":"12 - 15","wikipedia_url":"https://en.wikipedia.org/wiki/Bengal_(cat)"}`
+
+// theCatAPIServer mimics the thecatapi.com endpoints used by the provider,
+// with an empty search result when empty is set
+func theCatAPIServer(t *testing.T, empty bool) (*httptest.Server, *atomic.Value) {
+	t.Helper()
+	var last atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/img/abc.png" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(testutil.ValidPNGBytes())
+			return
+		}
+		last.Store(r.Clone(context.Background()))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/images/search":
+			if empty {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"id":"abc","url":"http://` + r.Host + `/img/abc.png","breeds":[` + theCatAPIBengal + `],"categories":[{"id":1,"name":"hats"}]}]`))
+		case "/v1/images/abc":
+			w.Write([]byte(`{"id":"abc","url":"http://` + r.Host + `/img/abc.png","breeds":[` + theCatAPIBengal + `]}`))
+		case "/v1/breeds":
+			w.Write([]byte(`[` + theCatAPIBengal + `,{"id":"abys","name":"Abyssinian"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &last
+}
+
+// TestTheCatAPI_FetchRandom tests searching with breeds and the API key
+func TestTheCatAPI_FetchRandom(t *testing.T) {
+	server, last := theCatAPIServer(t, false)
+	p := NewTheCatAPI("secret", nil, WithBaseURL(server.URL))
+	res, err := p.FetchRandom(context.Background(), WithTags("beng", "abys"))
+	testutil.AssertNoError(t, err, "FetchRandom should succeed")
+	testutil.AssertNotNil(t, res.Image, "image")
+	meta := res.Metadata
+
+	req := last.Load().(*http.Request)
+	testutil.AssertEqual(t, "/v1/images/search", req.URL.Path, "search path")
+	testutil.AssertEqual(t, "secret", req.Header.Get(theCatAPIKeyHeader), "API key header")
+	testutil.AssertEqual(t, "beng,abys", req.URL.Query().Get("breed_ids"), "breed filter")
+	testutil.AssertEqual(t, "1", req.URL.Query().Get("has_breeds"), "breed info requested")
+
+	testutil.AssertEqual(t, "abc", meta.GetID(), "ID")
+	testutil.AssertEqual(t, "image/png", meta.GetMIMEType(), "MIME type from extension")
+	testutil.AssertEqual(t, "hats", meta.GetTags()[0], "categories as tags")
+	testutil.AssertEqual(t, 1, len(meta.GetBreeds()), "breeds")
+	testutil.AssertEqual(t, "Bengal", meta.GetBreeds()[0].Name, "breed name")
+	testutil.AssertEqual(t, "12 - 15", meta.GetBreeds()[0].LifeSpan, "life span")
+	testutil.AssertTrue(t, meta.GetRequestID() != "", "request ID")
+}
+
+// TestTheCatAPI_GIF tests WithGIF limits the search to GIFs
+func TestTheCatAPI_GIF(t *testing.T) {
+	server, last := theCatAPIServer(t, false)
+	p := NewTheCatAPI("", nil, WithBaseURL(server.URL))
+	_, err := p.FetchRandom(context.Background(), WithGIF())
+	testutil.AssertNoError(t, err, "FetchRandom should succeed")
+	testutil.AssertEqual(t, "gif", last.Load().(*http.Request).URL.Query().Get("mime_types"), "GIF filter")
+}
+
+// TestTheCatAPI_NoResults tests an empty search
+func TestTheCatAPI_NoResults(t *testing.T) {
+	server, _ := theCatAPIServer(t, true)
+	p := NewTheCatAPI("", nil, WithBaseURL(server.URL))
+
+	_, err := p.FetchRandom(context.Background())
+	testutil.AssertTrue(t, errors.Is(err, ErrNoCat), "empty search should fail")
+}
+
+// TestTheCatAPI_FetchByID tests fetching a specific image without a key
+func TestTheCatAPI_FetchByID(t *testing.T) {
+	server, last := theCatAPIServer(t, false)
+	p := NewTheCatAPI("", nil, WithBaseURL(server.URL))
+
+	res, err := p.FetchByID(context.Background(), "abc")
+	testutil.AssertNoError(t, err, "FetchByID should succeed")
+	testutil.AssertNotNil(t, res.Image, "image")
+	testutil.AssertEqual(t, "Bengal", res.Metadata.GetBreeds()[0].Name, "breed")
+
+	req := last.Load().(*http.Request)
+	testutil.AssertEqual(t, "", req.Header.Get(theCatAPIKeyHeader), "no key header without a key")
+
+	_, err = p.FetchByID(context.Background(), "")
+	testutil.AssertTrue(t, errors.Is(err, ErrNoID), "empty ID should be rejected")
+}
+
+// TestTheCatAPI_ListTags tests listing breed IDs
+func TestTheCatAPI_ListTags(t *testing.T) {
+	server, _ := theCatAPIServer(t, false)
+	p := NewTheCatAPI("", nil, WithBaseURL(server.URL))
+
+	tags, err := p.ListTags(context.Background())
+	testutil.AssertNoError(t, err, "ListTags should succeed")
+	testutil.AssertEqual(t, 2, len(tags), "breed count")
+	testutil.AssertEqual(t, "beng", tags[0], "first breed ID")
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timeouts bounds the phases of each request attempt, so a stalled
+// connection or download fails with ErrTimeout instead of holding the
+// caller for the whole request timeout. Zero leaves a phase unbounded.
+type Timeouts struct {
+	Dial           time.Duration // connecting to the server or proxy
+	TLSHandshake   time.Duration
+	ResponseHeader time.Duration // from sending the request to the response headers
+	Body           time.Duration // reading the whole response body
+	Total          time.Duration // the whole attempt, as with WithTimeout
+}
+
+// DefaultTimeouts applies unless WithTimeouts is given. The total is left
+// to the http.Client.
+var DefaultTimeouts = Timeouts{
+	Dial:           10 * time.Second,
+	TLSHandshake:   10 * time.Second,
+	ResponseHeader: 20 * time.Second,
+	Body:           20 * time.Second,
+}
+
+// WithTimeouts bounds the phases of each request. A non-zero Total replaces
+// the timeout of the underlying http.Client like WithTimeout.
+func WithTimeouts(t Timeouts) Option {
+	return func(o *options) {
+		o.timeouts = t
+		if t.Total > 0 {
+			o.timeout = t.Total
+			o.hasTimeout = true
+		}
+	}
+}
+
+// phaseTimeout is why an attempt was canceled when a phase stalled
+type phaseTimeout struct {
+	phase string
+	after time.Duration
+}
+
+func (e *phaseTimeout) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.phase, e.after)
+}
+
+func (e *phaseTimeout) Timeout() bool {
+	return true
+}
+
+// phaseWatch cancels an attempt when one of its phases runs too long
+type phaseWatch struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// watch returns req under a context canceled when a phase of t runs over,
+// with the dial and TLS phases timed through httptrace. The caller must
+// call done once the attempt is over.
+func (t Timeouts) watch(req *http.Request) (*http.Request, *phaseWatch) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	w := &phaseWatch{ctx: ctx, cancel: cancel, timers: map[string]*time.Timer{}}
+
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) {
+			w.start("dial", t.Dial)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				w.stop("dial")
+			}
+		},
+		TLSHandshakeStart: func() {
+			w.start("tls handshake", t.TLSHandshake)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			w.stop("tls handshake")
+		},
+	})
+	w.start("response header", t.ResponseHeader)
+	return req.WithContext(ctx), w
+}
+
+// start times phase unless it is unbounded or already timed, dialing may
+// try several addresses
+func (w *phaseWatch) start(phase string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.timers[phase]; ok {
+		return
+	}
+	w.timers[phase] = time.AfterFunc(d, func() {
+		w.cancel(&phaseTimeout{phase: phase, after: d})
+	})
+}
+
+func (w *phaseWatch) stop(phase string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timer, ok := w.timers[phase]; ok {
+		timer.Stop()
+	}
+}
+
+// err replaces err with the phase timeout that caused it, if one did
+func (w *phaseWatch) err(err error) error {
+	var pt *phaseTimeout
+	if err != nil && errors.As(context.Cause(w.ctx), &pt) {
+		return pt
+	}
+	return err
+}
+
+// done stops the timers and releases the context
+func (w *phaseWatch) done() {
+	w.mu.Lock()
+	for _, timer := range w.timers {
+		timer.Stop()
+	}
+	w.mu.Unlock()
+	w.cancel(nil)
+}
+
+// body times reading body, ending the attempt when it is closed
+func (w *phaseWatch) body(body io.ReadCloser, d time.Duration) io.ReadCloser {
+	w.stop("response header")
+	w.start("body", d)
+	return &watchedBody{ReadCloser: body, watch: w}
+}
+
+type watchedBody struct {
+	io.ReadCloser
+	watch *phaseWatch
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = b.watch.err(err)
+	}
+	return n, err
+}
+
+func (b *watchedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.watch.done()
+	return err
+}
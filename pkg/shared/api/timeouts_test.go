@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// stallServer serves cats, stalling the first stalls requests for a second
+// either before the headers or halfway through the body
+type stallServer struct {
+	*httptest.Server
+	calls atomic.Int32
+}
+
+func newStallServer(t *testing.T, stalls int32, inBody bool) *stallServer {
+	s := &stallServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := []byte(testutil.ValidMetadataJSONWithURL("/image"))
+		if r.URL.Path == "/image" {
+			body = testutil.ValidPNGBytes()
+		}
+		if s.calls.Add(1) > stalls {
+			w.Write(body)
+			return
+		}
+		if inBody {
+			w.Write(body[:len(body)/2])
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// TestTimeouts_Phases tests a stalled phase fails the attempt early
+func TestTimeouts_Phases(t *testing.T) {
+	tests := []struct {
+		name     string
+		inBody   bool
+		timeouts Timeouts
+		phase    string
+	}{
+		{"response_header", false, Timeouts{ResponseHeader: 50 * time.Millisecond}, "response header"},
+		{"body", true, Timeouts{Body: 50 * time.Millisecond}, "body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStallServer(t, 1, tt.inBody)
+
+			start := time.Now()
+			_, _, err := NewClient(nil, WithBaseURL(server.URL), WithTimeouts(tt.timeouts), WithoutRetries()).RandomCat()
+			testutil.AssertTrue(t, errors.Is(err, ErrTimeout), "should be ErrTimeout, got "+errString(err))
+			testutil.AssertTrue(t, strings.Contains(err.Error(), tt.phase+" timed out"), "phase named in "+err.Error())
+			testutil.AssertTrue(t, time.Since(start) < 500*time.Millisecond, "failed before the server gave up")
+		})
+	}
+}
+
+// TestTimeouts_StallNotRetried tests a stalled attempt isn't retried, so
+// the caller hears about it within the phase timeout
+func TestTimeouts_StallNotRetried(t *testing.T) {
+	server := newStallServer(t, 1, false)
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	_, _, err := NewClient(nil, WithBaseURL(server.URL), WithRetryPolicy(policy),
+		WithTimeouts(Timeouts{ResponseHeader: 50 * time.Millisecond})).RandomCat()
+	testutil.AssertTrue(t, errors.Is(err, ErrTimeout), "should be ErrTimeout")
+	testutil.AssertEqual(t, int32(1), server.calls.Load(), "single attempt")
+}
+
+// TestWithTimeouts tests the total timeout and defaults
+func TestWithTimeouts(t *testing.T) {
+	o := newOptions(nil)
+	testutil.AssertEqual(t, DefaultTimeouts, o.timeouts, "defaults")
+	testutil.AssertTrue(t, !o.hasTimeout, "total left to the client")
+
+	o = newOptions([]Option{WithTimeouts(Timeouts{Body: time.Second, Total: 5 * time.Second})})
+	testutil.AssertEqual(t, time.Second, o.timeouts.Body, "body")
+	testutil.AssertEqual(t, time.Duration(0), o.timeouts.Dial, "unbounded dial")
+	testutil.AssertEqual(t, 5*time.Second, o.timeout, "total")
+	testutil.AssertTrue(t, o.hasTimeout, "total replaces the client timeout")
+}
+
+// TestPhaseWatch tests the timers of the phases
+func TestPhaseWatch(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+
+	t.Run("stopped_in_time", func(t *testing.T) {
+		_, w := Timeouts{}.watch(req)
+		defer w.done()
+		w.start("dial", 20*time.Millisecond)
+		w.stop("dial")
+		time.Sleep(40 * time.Millisecond)
+		testutil.AssertNoError(t, w.ctx.Err(), "not canceled")
+	})
+
+	t.Run("runs_over", func(t *testing.T) {
+		_, w := Timeouts{}.watch(req)
+		defer w.done()
+		w.start("dial", 10*time.Millisecond)
+		<-w.ctx.Done()
+
+		err := w.err(context.Canceled)
+		testutil.AssertTrue(t, isTimeout(err), "a timeout")
+		testutil.AssertEqual(t, "dial timed out after 10ms", err.Error(), "message")
+	})
+
+	t.Run("other_errors_kept", func(t *testing.T) {
+		_, w := Timeouts{}.watch(req)
+		w.done()
+		errOther := errors.New("connection reset")
+		testutil.AssertTrue(t, w.err(errOther) == errOther, "not a phase timeout")
+	})
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestTiming is how long the phases of one HTTP request attempt took,
+// recorded with WithTracing. Phases the attempt skipped, such as DNS and
+// connecting on a reused connection, are zero.
+type RequestTiming struct {
+	URL          string
+	Attempt      int
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	TTFB         time.Duration // from sending the request to the first response byte
+	Transfer     time.Duration // from the first response byte to the body being closed
+	Total        time.Duration
+	ReusedConn   bool
+	Err          error // why the attempt failed, a *StatusError for non-2xx responses
+}
+
+// LogValue logs the timings as a group of durations
+func (t RequestTiming) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("url", t.URL),
+		slog.Int("attempt", t.Attempt),
+		slog.Duration("dns", t.DNS),
+		slog.Duration("connect", t.Connect),
+		slog.Duration("tls", t.TLSHandshake),
+		slog.Duration("ttfb", t.TTFB),
+		slog.Duration("transfer", t.Transfer),
+		slog.Duration("total", t.Total),
+		slog.Bool("reused_conn", t.ReusedConn),
+	}
+	if t.Err != nil {
+		attrs = append(attrs, slog.Any("err", t.Err))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// WithTracing records the timings of every request of a fetch in
+// FetchResult.Timings, and logs each at debug level
+func WithTracing() Option {
+	return func(o *options) {
+		o.tracing = true
+	}
+}
+
+// timingLog collects the timings of one fetch
+type timingLog struct {
+	mu      sync.Mutex
+	timings []RequestTiming
+}
+
+func (l *timingLog) add(t RequestTiming) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timings = append(l.timings, t)
+}
+
+func (l *timingLog) list() []RequestTiming {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]RequestTiming(nil), l.timings...)
+}
+
+// requestTracer times one attempt through httptrace
+type requestTracer struct {
+	mu        sync.Mutex
+	start     time.Time
+	dnsStart  time.Time
+	connStart time.Time
+	tlsStart  time.Time
+	firstByte time.Time
+	timing    RequestTiming
+	now       func() time.Time
+}
+
+// trace starts timing an attempt of req, returning it with the hooks set
+func (l *timingLog) trace(req *http.Request, attempt int, now func() time.Time) (*http.Request, *requestTracer) {
+	t := &requestTracer{now: now}
+	t.start = t.now()
+	t.timing = RequestTiming{URL: req.URL.String(), Attempt: attempt}
+
+	ctx := httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mark(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.since(t.dnsStart, &t.timing.DNS)
+		},
+		ConnectStart: func(network, addr string) {
+			t.mark(&t.connStart)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.since(t.connStart, &t.timing.Connect)
+		},
+		TLSHandshakeStart: func() {
+			t.mark(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.since(t.tlsStart, &t.timing.TLSHandshake)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.timing.ReusedConn = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mark(&t.firstByte)
+			t.since(t.start, &t.timing.TTFB)
+		},
+	})
+	return req.WithContext(ctx), t
+}
+
+// mark records the start of a phase, the first time only since dialing
+// may try several addresses
+func (t *requestTracer) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if at.IsZero() {
+		*at = t.now()
+	}
+}
+
+// since records the duration of a phase that started at start
+func (t *requestTracer) since(start time.Time, d *time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !start.IsZero() && *d == 0 {
+		*d = t.now().Sub(start)
+	}
+}
+
+// finish ends the attempt, with err if it failed
+func (t *requestTracer) finish(err error) RequestTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	end := t.now()
+	if !t.firstByte.IsZero() {
+		t.timing.Transfer = end.Sub(t.firstByte)
+	}
+	t.timing.Total = end.Sub(t.start)
+	t.timing.Err = err
+	return t.timing
+}
+
+// onClose runs a func once the body is closed
+type onClose struct {
+	io.ReadCloser
+	once sync.Once
+	fn   func()
+}
+
+func (b *onClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.fn)
+	return err
+}
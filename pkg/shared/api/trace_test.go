@@ -0,0 +1,86 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestWithTracing tests each request of a fetch is timed
+func TestWithTracing(t *testing.T) {
+	server := catServer(t)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	res, err := NewClient(nil, WithBaseURL(server.URL), WithTracing(), WithLogger(logger)).FetchRandom(t.Context())
+	testutil.AssertNoError(t, err, "FetchRandom should succeed")
+
+	testutil.AssertEqual(t, 2, len(res.Timings), "metadata and image")
+	testutil.AssertTrue(t, strings.HasPrefix(res.Timings[0].URL, server.URL+"/cat"), "metadata first")
+	testutil.AssertEqual(t, server.URL+"/image", res.Timings[1].URL, "image second")
+	for _, timing := range res.Timings {
+		testutil.AssertEqual(t, 1, timing.Attempt, "attempt")
+		testutil.AssertTrue(t, timing.TTFB > 0, "time to first byte")
+		testutil.AssertTrue(t, timing.Total >= timing.TTFB, "total covers the first byte")
+		testutil.AssertNoError(t, timing.Err, "no error")
+	}
+	testutil.AssertTrue(t, res.Timings[0].Connect > 0 && !res.Timings[0].ReusedConn, "new connection")
+	testutil.AssertEqual(t, 2, strings.Count(buf.String(), `msg="Request timing"`), "timings logged")
+	testutil.AssertTrue(t, strings.Contains(buf.String(), "timing.ttfb="), "durations logged")
+}
+
+// TestWithTracing_Retries tests failed attempts are timed too
+func TestWithTracing_Retries(t *testing.T) {
+	server, _ := flakyServer(t, 1, http.StatusServiceUnavailable)
+	policy := DefaultRetryPolicy
+	policy.BaseDelay = time.Millisecond
+
+	res, err := NewClient(nil, WithBaseURL(server.URL), WithTracing(), WithRetryPolicy(policy)).FetchRandom(t.Context())
+	testutil.AssertNoError(t, err, "FetchRandom should succeed")
+
+	testutil.AssertEqual(t, 3, len(res.Timings), "failed attempt, retry and image")
+	var statusErr *StatusError
+	testutil.AssertTrue(t, errors.As(res.Timings[0].Err, &statusErr), "first attempt failed")
+	testutil.AssertEqual(t, 2, res.Timings[1].Attempt, "second attempt")
+	testutil.AssertNoError(t, res.Timings[1].Err, "second attempt succeeded")
+}
+
+// TestWithTracing_Off tests nothing is recorded by default
+func TestWithTracing_Off(t *testing.T) {
+	res, err := NewClient(nil, WithBaseURL(catServer(t).URL)).FetchRandom(t.Context())
+	testutil.AssertNoError(t, err, "FetchRandom should succeed")
+	testutil.AssertEqual(t, 0, len(res.Timings), "no timings")
+}
+
+// TestRequestTracer tests phases are measured once from their first start
+func TestRequestTracer(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tick := func(d time.Duration) { now = now.Add(d) }
+	tr := &requestTracer{now: func() time.Time { return now }}
+	tr.start = now
+
+	tick(time.Millisecond)
+	tr.mark(&tr.connStart)
+	tick(time.Millisecond)
+	tr.mark(&tr.connStart) // second address
+	tick(3 * time.Millisecond)
+	tr.since(tr.connStart, &tr.timing.Connect)
+	tick(time.Millisecond)
+	tr.since(tr.connStart, &tr.timing.Connect) // second address done
+	tr.mark(&tr.firstByte)
+	tr.since(tr.start, &tr.timing.TTFB)
+	tick(10 * time.Millisecond)
+
+	timing := tr.finish(nil)
+	testutil.AssertEqual(t, 4*time.Millisecond, timing.Connect, "connect from the first start")
+	testutil.AssertEqual(t, 6*time.Millisecond, timing.TTFB, "time to first byte")
+	testutil.AssertEqual(t, 10*time.Millisecond, timing.Transfer, "transfer")
+	testutil.AssertEqual(t, 16*time.Millisecond, timing.Total, "total")
+	testutil.AssertEqual(t, time.Duration(0), timing.DNS, "skipped phase")
+}
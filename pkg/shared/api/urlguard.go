@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var ErrUnsafeImageURL = fmt.Errorf("%w: unsafe image url", ErrMetadata)
+
+// lookupIPAddr resolves image hosts before they are fetched
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// WithAllowedHosts lets image URLs in metadata point at hosts other than
+// the one of the base URL, such as a CDN. A leading "*." matches any
+// subdomain, e.g. "*.example.com".
+func WithAllowedHosts(hosts ...string) Option {
+	return func(o *options) {
+		o.allowedHosts = append(o.allowedHosts, hosts...)
+	}
+}
+
+// checkImageURL guards against metadata sending us to fetch from somewhere
+// we shouldn't. The URL must be http(s) on the base URL's host, which is
+// trusted like the metadata itself and may be a mirror on the local
+// network, or on an allowed host. An allowed host name must only resolve to
+// public addresses, an allowed IP address was asked for and is trusted.
+// The image client checks every redirect the same way, and its transport
+// checks the addresses it connects to, see guardDial.
+func (o *options) checkImageURL(ctx context.Context, imgURL string) error {
+	u, err := url.Parse(imgURL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnsafeImageURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q", ErrUnsafeImageURL, u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: no host", ErrUnsafeImageURL)
+	}
+
+	if o.baseHost(host) {
+		return nil
+	}
+	if !o.hostAllowed(host) {
+		return fmt.Errorf("%w: host %s not allowed", ErrUnsafeImageURL, host)
+	}
+
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	addrs, err := lookupIPAddr(ctx, host)
+	if err != nil {
+		return transportError(err)
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrUnsafeImageURL, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// baseHost reports whether host is the host of the base URL
+func (o *options) baseHost(host string) bool {
+	base, err := url.Parse(o.baseURL)
+	return err == nil && strings.EqualFold(base.Hostname(), host)
+}
+
+// needsPublicIP reports whether connections to host must go to public
+// addresses, which is the case for allowed host names. The base URL's host
+// and allowed IP addresses are trusted.
+func (o *options) needsPublicIP(host string) bool {
+	return !o.baseHost(host) && net.ParseIP(host) == nil && o.hostAllowed(host)
+}
+
+// hostAllowed reports whether host matches one given with WithAllowedHosts
+func (o *options) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	return slices.ContainsFunc(o.allowedHosts, func(allowed string) bool {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			return strings.HasSuffix(host, suffix) && strings.HasPrefix(suffix, ".")
+		}
+		return host == allowed
+	})
+}
+
+// publicIP reports whether ip is routable on the internet, as opposed to
+// loopback, private, link-local or otherwise special
+func publicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// checkRedirect re-checks every redirect of an image download like the
+// image URL, then hands it to next, or stops after 10 redirects as the
+// http.Client does by default
+func (o *options) checkRedirect(next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if err := o.checkImageURL(req.Context(), req.URL.String()); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+// imageGuardKey marks the context of an image download with its options,
+// for guardDial
+type imageGuardKey struct{}
+
+// withImageGuard marks ctx for guardDial to check the connections made for
+// it against o
+func withImageGuard(ctx context.Context, o *options) context.Context {
+	return context.WithValue(ctx, imageGuardKey{}, o)
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// guardDial wraps dial so that image downloads resolve allowed host names
+// once and connect only to the public addresses they resolve to. Checking
+// the addresses actually dialed keeps DNS rebinding from getting around
+// checkImageURL. Other connections, such as to a proxy, which then resolves
+// the host itself, are dialed as they are.
+func guardDial(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		o, ok := ctx.Value(imageGuardKey{}).(*options)
+		host, port, err := net.SplitHostPort(addr)
+		if !ok || err != nil || !o.needsPublicIP(host) {
+			return dial(ctx, network, addr)
+		}
+
+		addrs, err := lookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) == 0 {
+			return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		for _, a := range addrs {
+			if !publicIP(a.IP) {
+				return nil, fmt.Errorf("%w: %s resolves to %s", ErrUnsafeImageURL, host, a.IP)
+			}
+		}
+		var errs []error
+		for _, a := range addrs {
+			conn, err := dial(ctx, network, net.JoinHostPort(a.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+		}
+		return nil, errors.Join(errs...)
+	}
+}
+
+// guardTransport makes t dial through guardDial
+func guardTransport(t *http.Transport) {
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	t.DialContext = guardDial(dial)
+}
+
+// guardedDefault is a guarded clone of http.DefaultTransport, made again
+// when that is replaced
+var guardedDefault struct {
+	sync.Mutex
+	from, to *http.Transport
+}
+
+// defaultTransport stands in for a nil transport. It is a guarded clone of
+// http.DefaultTransport, or http.DefaultTransport itself when that has been
+// replaced by something other than an *http.Transport.
+func defaultTransport() http.RoundTripper {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultTransport
+	}
+	guardedDefault.Lock()
+	defer guardedDefault.Unlock()
+	if guardedDefault.from != t {
+		guardedDefault.from = t
+		guardedDefault.to = t.Clone()
+		guardTransport(guardedDefault.to)
+	}
+	return guardedDefault.to
+}
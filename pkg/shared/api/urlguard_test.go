@@ -0,0 +1,234 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestCheckImageURL tests which image URLs may be fetched
+func TestCheckImageURL(t *testing.T) {
+	saved := lookupIPAddr
+	defer func() { lookupIPAddr = saved }()
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "cdn.example.com", "a.cdn.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		case "rebind.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.8")}}, nil
+		case "metadata.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("169.254.169.254")}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	o := newOptions([]Option{
+		WithBaseURL("http://localhost:8080"),
+		WithAllowedHosts("CDN.example.com", "*.cdn.example.com", "rebind.example.com", "metadata.example.com", "192.168.1.20", "missing.example.com"),
+	})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{"base_host", "http://localhost:8080/cat/abc", nil},
+		{"base_host_other_port", "https://LOCALHOST/cat/abc", nil},
+		{"allowed_host", "https://cdn.example.com/abc.png", nil},
+		{"allowed_subdomain", "https://a.cdn.example.com/abc.png", nil},
+		{"allowed_ip", "http://192.168.1.20/abc.png", nil},
+		{"unknown_host", "https://evil.example.com/abc.png", ErrUnsafeImageURL},
+		{"loopback_ip", "http://127.0.0.1:6379/", ErrUnsafeImageURL},
+		{"cloud_metadata_ip", "http://169.254.169.254/latest/meta-data", ErrUnsafeImageURL},
+		{"resolves_private", "https://rebind.example.com/abc.png", ErrUnsafeImageURL},
+		{"resolves_link_local", "https://metadata.example.com/abc.png", ErrUnsafeImageURL},
+		{"unresolvable", "https://missing.example.com/abc.png", ErrNetwork},
+		{"file_scheme", "file:///etc/passwd", ErrUnsafeImageURL},
+		{"no_host", "http:///abc.png", ErrUnsafeImageURL},
+		{"unparsable", "http://[::1/abc.png", ErrUnsafeImageURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := o.checkImageURL(context.Background(), tt.url)
+			if tt.wantErr == nil {
+				testutil.AssertNoError(t, err, "URL should be allowed")
+				return
+			}
+			testutil.AssertTrue(t, errors.Is(err, tt.wantErr), "should be "+tt.wantErr.Error()+", got "+errString(err))
+		})
+	}
+}
+
+// TestPublicIP tests sorting addresses into public and special ones
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.0.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fc00::1", false},
+		{"fe80::1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, publicIP(net.ParseIP(tt.ip)), "public")
+		})
+	}
+}
+
+// TestClient_UnsafeImageURL tests metadata pointing elsewhere isn't fetched
+func TestClient_UnsafeImageURL(t *testing.T) {
+	var victim atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		victim.Add(1)
+	}))
+	defer internal.Close()
+
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// same machine, other host name than the base URL
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL(localhost(internal.URL) + "/admin")))
+	}))
+	defer metadata.Close()
+
+	_, _, err := NewClient(nil, WithBaseURL(metadata.URL)).RandomCat()
+	testutil.AssertTrue(t, errors.Is(err, ErrUnsafeImageURL), "should be ErrUnsafeImageURL, got "+errString(err))
+	testutil.AssertEqual(t, int32(0), victim.Load(), "internal server untouched")
+}
+
+// TestClient_UnsafeRedirect tests redirects of image downloads are checked
+// like the image URL
+func TestClient_UnsafeRedirect(t *testing.T) {
+	saved := lookupIPAddr
+	defer func() { lookupIPAddr = saved }()
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+
+	var victim atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		victim.Add(1)
+	}))
+	defer internal.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(internal.URL, "http://"))
+
+	tests := []struct {
+		name     string
+		location string
+	}{
+		{"to_loopback", internal.URL + "/admin"},
+		{"to_cloud_metadata", "http://169.254.169.254/latest/meta-data"},
+		{"to_allowed_host_on_loopback", "http://cdn.example.com:" + port + "/admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/image" {
+					http.Redirect(w, r, tt.location, http.StatusFound)
+					return
+				}
+				w.Write([]byte(testutil.ValidMetadataJSONWithURL(localhost(server.URL) + "/image")))
+			}))
+			defer server.Close()
+
+			c := NewClient(nil, WithBaseURL(localhost(server.URL)), WithAllowedHosts("cdn.example.com"))
+			_, _, err := c.RandomCat()
+			testutil.AssertTrue(t, errors.Is(err, ErrUnsafeImageURL), "should be ErrUnsafeImageURL, got "+errString(err))
+			testutil.AssertTrue(t, strings.Contains(errString(err), tt.location), "redirect should be refused, got "+errString(err))
+			testutil.AssertEqual(t, int32(0), victim.Load(), "internal server untouched")
+		})
+	}
+}
+
+// localhost names the host of a test server URL, so other URLs on
+// 127.0.0.1 aren't on the base host
+func localhost(serverURL string) string {
+	return strings.Replace(serverURL, "127.0.0.1", "localhost", 1)
+}
+
+// TestClient_DNSRebinding tests a host that resolves to a public address
+// when checked and to loopback when connected to isn't fetched from
+func TestClient_DNSRebinding(t *testing.T) {
+	var victim atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		victim.Add(1)
+	}))
+	defer internal.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(internal.URL, "http://"))
+
+	saved := lookupIPAddr
+	defer func() { lookupIPAddr = saved }()
+	var lookups atomic.Int32
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if lookups.Add(1) == 1 {
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		}
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testutil.ValidMetadataJSONWithURL("http://rebind.example.com:" + port + "/admin")))
+	}))
+	defer metadata.Close()
+
+	c := NewClient(nil, WithBaseURL(metadata.URL), WithAllowedHosts("rebind.example.com"))
+	_, _, err := c.RandomCat()
+	testutil.AssertTrue(t, errors.Is(err, ErrUnsafeImageURL), "should be ErrUnsafeImageURL, got "+errString(err))
+	testutil.AssertEqual(t, int32(2), lookups.Load(), "resolved when checked and when connecting")
+	testutil.AssertEqual(t, int32(0), victim.Load(), "internal server untouched")
+}
+
+// TestGuardDial tests which connections are checked and that checked ones
+// go to the addresses that were checked
+func TestGuardDial(t *testing.T) {
+	saved := lookupIPAddr
+	defer func() { lookupIPAddr = saved }()
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+	}
+
+	var dialed string
+	dial := guardDial(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = addr
+		return nil, errors.New("not dialing")
+	})
+	o := newOptions([]Option{WithBaseURL("http://mirror.lan"), WithAllowedHosts("cdn.example.com")})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		addr string
+		want string
+	}{
+		{"allowed_host", withImageGuard(context.Background(), o), "cdn.example.com:443", "93.184.216.34:443"},
+		{"base_host", withImageGuard(context.Background(), o), "mirror.lan:80", "mirror.lan:80"},
+		{"proxy", withImageGuard(context.Background(), o), "proxy.lan:3128", "proxy.lan:3128"},
+		{"not_an_image", context.Background(), "cdn.example.com:443", "cdn.example.com:443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _ = dial(tt.ctx, "tcp", tt.addr)
+			testutil.AssertEqual(t, tt.want, dialed, "dialed address")
+		})
+	}
+}
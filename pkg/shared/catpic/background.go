@@ -0,0 +1,223 @@
+package catpic
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"github.com/bmj2728/catfetch/pkg/shared/imgutil"
+	"github.com/g4s8/hexcolor"
+)
+
+var ErrBackground = errors.New("invalid background")
+
+type BackgroundKind int
+
+const (
+	BackgroundNone         BackgroundKind = iota // nothing is drawn behind the image
+	BackgroundSolid                              // the letterbox is filled with Color
+	BackgroundCheckerboard                       // alternating Color/AltColor squares, shows transparency
+	BackgroundBlur                               // a blurred copy of the image covers the area, tinted by Color
+)
+
+// Background describes what is drawn in the image area behind the cat
+type Background struct {
+	Kind     BackgroundKind
+	Color    color.NRGBA
+	AltColor color.NRGBA // second checkerboard color
+	CellSize unit.Dp     // checkerboard square size
+	Radius   int         // blur radius in pixels of the reduced backdrop copy
+}
+
+var (
+	checkerLight = color.NRGBA{R: 204, G: 204, B: 204, A: 255}
+	checkerDark  = color.NRGBA{R: 153, G: 153, B: 153, A: 255}
+)
+
+const (
+	defaultCheckerCell = unit.Dp(8)
+
+	// the backdrop is blurred at this size and stretched to cover the area,
+	// which is far cheaper than blurring at full resolution and looks the same
+	backdropSize      = 96
+	defaultBlurRadius = 4
+)
+
+// default tint darkens the backdrop a little so the cat stands out
+var defaultBlurTint = color.NRGBA{A: 80}
+
+// SolidBackground fills the letterbox with a single color
+func SolidBackground(c color.NRGBA) Background {
+	return Background{Kind: BackgroundSolid, Color: c}
+}
+
+// CheckerboardBackground is the usual light/dark gray transparency grid
+func CheckerboardBackground() Background {
+	return Background{
+		Kind:     BackgroundCheckerboard,
+		Color:    checkerLight,
+		AltColor: checkerDark,
+		CellSize: defaultCheckerCell,
+	}
+}
+
+// BlurredBackground fills the area with a blurred copy of the cat, like
+// the "cover" mode of modern gallery apps
+func BlurredBackground() Background {
+	return Background{
+		Kind:   BackgroundBlur,
+		Color:  defaultBlurTint,
+		Radius: defaultBlurRadius,
+	}
+}
+
+// ParseBackground reads a background setting, one of none, solid:#rrggbb,
+// checker or blur
+func ParseBackground(s string) (Background, error) {
+	kind, arg, hasArg := strings.Cut(strings.TrimSpace(s), ":")
+	switch kind = strings.ToLower(kind); {
+	case kind == "solid" && arg != "":
+		c, err := hexcolor.Parse(arg)
+		if err != nil {
+			return Background{}, fmt.Errorf("%w %q: %w", ErrBackground, s, err)
+		}
+		return SolidBackground(color.NRGBA(c)), nil
+	case hasArg:
+		// only solid takes an argument, and needs one
+	case kind == "" || kind == "none":
+		return Background{}, nil
+	case kind == "checker":
+		return CheckerboardBackground(), nil
+	case kind == "blur":
+		return BlurredBackground(), nil
+	}
+	return Background{}, fmt.Errorf("%w %q, want none, solid:#rrggbb, checker or blur", ErrBackground, s)
+}
+
+// checker caches the tile image so it's only rebuilt when the area or colors change
+type checker struct {
+	cols, rows int
+	a, b       color.NRGBA
+	imgOp      paint.ImageOp
+}
+
+func (p *CatPic) SetBackground(bg Background) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.background = bg
+}
+
+func (p *CatPic) GetBackground() Background {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.background
+}
+
+// checkerOp returns a one-pixel-per-cell checkerboard op, reusing the last one if it still fits
+func (p *CatPic) checkerOp(cols, rows int, a, b color.NRGBA) paint.ImageOp {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c := p.checker
+	if c != nil && c.cols == cols && c.rows == rows && c.a == a && c.b == b {
+		return c.imgOp
+	}
+	imgOp := paint.NewImageOp(imgutil.Checkerboard(cols, rows, a, b))
+	imgOp.Filter = paint.FilterNearest
+	p.checker = &checker{cols: cols, rows: rows, a: a, b: b, imgOp: imgOp}
+	return imgOp
+}
+
+// backdrop caches the blurred copy of the image it was made from
+type backdrop struct {
+	src    image.Image
+	radius int
+	imgOp  paint.ImageOp
+}
+
+// backdropOp returns the blurred backdrop for the current image, building it on first use
+func (p *CatPic) backdropOp(radius int) (paint.ImageOp, bool) {
+	p.mu.Lock()
+	img := p.img
+	bd := p.backdrop
+	p.mu.Unlock()
+
+	if img == nil {
+		return paint.ImageOp{}, false
+	}
+	if bd != nil && bd.src == img && bd.radius == radius {
+		return bd.imgOp, true
+	}
+
+	// build outside the lock, the source image is never mutated
+	small := imgutil.Downscale(img, backdropSize, backdropSize)
+	bd = &backdrop{
+		src:    img,
+		radius: radius,
+		imgOp:  paint.NewImageOp(imgutil.Blur(small, radius)),
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backdrop = bd
+	return bd.imgOp, true
+}
+
+// drawBackground paints bg over the full size of the area
+func (p *CatPic) drawBackground(gtx layout.Context, bg Background, size image.Point) {
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+
+	switch bg.Kind {
+	case BackgroundSolid:
+		paint.ColorOp{Color: bg.Color}.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+
+	case BackgroundCheckerboard:
+		cell := gtx.Dp(bg.CellSize)
+		if cell <= 0 {
+			cell = gtx.Dp(defaultCheckerCell)
+		}
+		cell = max(cell, 1)
+		cols := (size.X + cell - 1) / cell
+		rows := (size.Y + cell - 1) / cell
+		if cols == 0 || rows == 0 {
+			return
+		}
+
+		imgOp := p.checkerOp(cols, rows, bg.Color, bg.AltColor)
+		// scale each pixel of the tile up to one cell
+		scale := f32.Affine2D{}.Scale(f32.Point{}, f32.Pt(float32(cell), float32(cell)))
+		defer op.Affine(scale).Push(gtx.Ops).Pop()
+		imgOp.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+
+	case BackgroundBlur:
+		radius := bg.Radius
+		if radius <= 0 {
+			radius = defaultBlurRadius
+		}
+		imgOp, ok := p.backdropOp(radius)
+		if !ok {
+			return
+		}
+
+		gtx.Constraints = layout.Exact(size)
+		widget.Image{
+			Src:      imgOp,
+			Fit:      widget.Cover,
+			Position: layout.Center,
+		}.Layout(gtx)
+
+		// tint over the backdrop
+		paint.ColorOp{Color: bg.Color}.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+	}
+}
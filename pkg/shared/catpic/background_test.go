@@ -0,0 +1,227 @@
+package catpic
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestCatPic_Background_Default tests that no background is drawn by default
+func TestCatPic_Background_Default(t *testing.T) {
+	catPic := NewCatImage(nil)
+	testutil.AssertEqual(t, BackgroundNone, catPic.GetBackground().Kind, "default background kind")
+}
+
+// TestCatPic_SetBackground tests storing background settings
+func TestCatPic_SetBackground(t *testing.T) {
+	catPic := NewCatImage(nil)
+
+	solid := SolidBackground(color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	catPic.SetBackground(solid)
+	testutil.AssertEqual(t, solid, catPic.GetBackground(), "solid background")
+
+	checker := CheckerboardBackground()
+	catPic.SetBackground(checker)
+	testutil.AssertEqual(t, checker, catPic.GetBackground(), "checkerboard background")
+}
+
+// TestCatPic_Draw_WithBackground tests that a background fills the whole area
+func TestCatPic_Draw_WithBackground(t *testing.T) {
+	backgrounds := []struct {
+		name string
+		bg   Background
+	}{
+		{name: "solid", bg: SolidBackground(color.NRGBA{R: 40, G: 42, B: 54, A: 255})},
+		{name: "checkerboard", bg: CheckerboardBackground()},
+	}
+
+	for _, tt := range backgrounds {
+		t.Run(tt.name+"_with_image", func(t *testing.T) {
+			catPic := NewCatImage(testutil.CreateColorImage(1000, 100, 255, 0, 0))
+			catPic.SetBackground(tt.bg)
+
+			var ops op.Ops
+			gtx := layout.Context{
+				Ops: &ops,
+				Constraints: layout.Constraints{
+					Min: image.Pt(0, 0),
+					Max: image.Pt(400, 500),
+				},
+			}
+
+			dims := catPic.Draw(gtx)
+			testutil.AssertEqual(t, image.Pt(400, 500), dims.Size, "background should fill the area")
+		})
+
+		t.Run(tt.name+"_without_image", func(t *testing.T) {
+			catPic := NewCatImage(nil)
+			catPic.SetBackground(tt.bg)
+
+			var ops op.Ops
+			gtx := layout.Context{
+				Ops: &ops,
+				Constraints: layout.Constraints{
+					Min: image.Pt(0, 0),
+					Max: image.Pt(300, 200),
+				},
+			}
+
+			dims := catPic.Draw(gtx)
+			testutil.AssertEqual(t, image.Pt(300, 200), dims.Size, "background should fill the area")
+		})
+	}
+
+	t.Run("checkerboard_zero_area", func(t *testing.T) {
+		catPic := NewCatImage(nil)
+		catPic.SetBackground(CheckerboardBackground())
+
+		var ops op.Ops
+		gtx := layout.Context{
+			Ops:         &ops,
+			Constraints: layout.Constraints{},
+		}
+
+		testutil.AssertNoPanic(t, func() {
+			_ = catPic.Draw(gtx)
+		}, "should not panic with zero area")
+	})
+}
+
+// TestCatPic_Draw_BlurredBackground tests the blurred cover backdrop
+func TestCatPic_Draw_BlurredBackground(t *testing.T) {
+	newGtx := func() layout.Context {
+		return layout.Context{
+			Ops: new(op.Ops),
+			Constraints: layout.Constraints{
+				Min: image.Pt(0, 0),
+				Max: image.Pt(400, 500),
+			},
+		}
+	}
+
+	t.Run("fills_area", func(t *testing.T) {
+		catPic := NewCatImage(testutil.CreateGradientImage(800, 200))
+		catPic.SetBackground(BlurredBackground())
+
+		dims := catPic.Draw(newGtx())
+		testutil.AssertEqual(t, image.Pt(400, 500), dims.Size, "backdrop should fill the area")
+	})
+
+	t.Run("reuses_backdrop_for_same_image", func(t *testing.T) {
+		catPic := NewCatImage(testutil.CreateGradientImage(800, 200))
+		catPic.SetBackground(BlurredBackground())
+
+		catPic.Draw(newGtx())
+		first := catPic.backdrop
+		testutil.AssertNotNil(t, first, "backdrop should be built on first draw")
+
+		catPic.Draw(newGtx())
+		testutil.AssertTrue(t, first == catPic.backdrop, "backdrop should be cached")
+
+		catPic.SetImage(testutil.CreateGradientImage(300, 300))
+		catPic.Draw(newGtx())
+		testutil.AssertTrue(t, first != catPic.backdrop, "backdrop should be rebuilt for a new image")
+	})
+
+	t.Run("no_image", func(t *testing.T) {
+		catPic := NewCatImage(nil)
+		catPic.SetBackground(BlurredBackground())
+
+		dims := catPic.Draw(newGtx())
+		testutil.AssertEqual(t, image.Pt(400, 500), dims.Size, "area should still be filled")
+		testutil.AssertNil(t, catPic.backdrop, "no backdrop without an image")
+	})
+}
+
+// TestCatPic_AlphaCheckerboard tests transparency detection and the checkerboard under it
+func TestCatPic_AlphaCheckerboard(t *testing.T) {
+	transparent := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	opaque := testutil.CreateColorImage(200, 100, 0, 0, 255)
+
+	t.Run("detects_alpha", func(t *testing.T) {
+		catPic := NewCatImage(transparent)
+		testutil.AssertTrue(t, catPic.HasAlpha(), "transparent image from constructor")
+
+		catPic.SetImage(opaque)
+		testutil.AssertFalse(t, catPic.HasAlpha(), "opaque image")
+
+		catPic.SetImage(transparent)
+		testutil.AssertTrue(t, catPic.HasAlpha(), "transparent image from SetImage")
+
+		catPic.SetImage(nil)
+		testutil.AssertFalse(t, catPic.HasAlpha(), "nil image")
+	})
+
+	t.Run("checkerboard_keeps_image_dimensions", func(t *testing.T) {
+		catPic := NewCatImage(transparent)
+		catPic.SetAlphaCheckerboard(true)
+
+		var ops op.Ops
+		gtx := layout.Context{
+			Ops: &ops,
+			Constraints: layout.Constraints{
+				Min: image.Pt(0, 0),
+				Max: image.Pt(400, 400),
+			},
+		}
+
+		dims := catPic.Draw(gtx)
+		testutil.AssertEqual(t, 400, dims.Size.X, "should use full width")
+		testutil.AssertTrue(t, dims.Size.Y < 400, "should keep the image aspect ratio")
+		testutil.AssertNotNil(t, catPic.checker, "checkerboard should have been drawn")
+	})
+
+	t.Run("no_checkerboard_for_opaque_images", func(t *testing.T) {
+		catPic := NewCatImage(opaque)
+		catPic.SetAlphaCheckerboard(true)
+
+		var ops op.Ops
+		gtx := layout.Context{
+			Ops: &ops,
+			Constraints: layout.Constraints{
+				Min: image.Pt(0, 0),
+				Max: image.Pt(400, 400),
+			},
+		}
+
+		catPic.Draw(gtx)
+		testutil.AssertNil(t, catPic.checker, "opaque image needs no checkerboard")
+	})
+}
+
+// TestParseBackground tests reading background settings
+func TestParseBackground(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Background
+		wantErr bool
+	}{
+		{value: "", want: Background{}},
+		{value: "none", want: Background{}},
+		{value: "solid:#282a36", want: SolidBackground(color.NRGBA{R: 40, G: 42, B: 54, A: 255})},
+		{value: " Checker ", want: CheckerboardBackground()},
+		{value: "blur", want: BlurredBackground()},
+		{value: "solid", wantErr: true},
+		{value: "solid:", wantErr: true},
+		{value: "solid:red", wantErr: true},
+		{value: "blur:8", wantErr: true},
+		{value: "stripes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			bg, err := ParseBackground(tt.value)
+			if tt.wantErr {
+				testutil.AssertTrue(t, errors.Is(err, ErrBackground), "should be ErrBackground")
+				return
+			}
+			testutil.AssertNoError(t, err, "should parse")
+			testutil.AssertEqual(t, tt.want, bg, "background")
+		})
+	}
+}
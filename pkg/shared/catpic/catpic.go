@@ -5,19 +5,30 @@ import (
 	"sync"
 
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/op/paint"
 	"gioui.org/widget"
+	"github.com/bmj2728/catfetch/pkg/shared/imgutil"
 )
 
 type CatPic struct {
-	img       image.Image
-	mu        sync.Mutex
-	isLoading bool
+	img          image.Image
+	imgOp        paint.ImageOp // prepared once in SetImage when convertNRGBA is on
+	hasImgOp     bool
+	mu           sync.Mutex
+	isLoading    bool
+	convertNRGBA bool
+	background   Background
+	checker      *checker
+	backdrop     *backdrop
+	hasAlpha     bool // the current image has transparent pixels
+	alphaChecker bool // draw a checkerboard under transparent images
 }
 
 func NewCatImage(img image.Image) *CatPic {
 	return &CatPic{
-		img: img,
+		img:      img,
+		hasAlpha: imgutil.HasAlpha(img),
 	}
 }
 
@@ -33,10 +44,54 @@ func (p *CatPic) GetImage() image.Image {
 	return p.img
 }
 
+// SetConvertNRGBA controls whether SetImage converts incoming images to
+// *image.NRGBA and prepares the paint op up front, off by default. Decoders
+// usually return YCbCr or paletted images, which otherwise get converted on
+// every frame, so turning it on trades a copy of each image for steadier
+// frame times.
+func (p *CatPic) SetConvertNRGBA(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.convertNRGBA = enabled
+}
+
+// HasAlpha reports whether the current image has any transparent pixels
+func (p *CatPic) HasAlpha() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.hasAlpha
+}
+
+// SetAlphaCheckerboard controls whether transparent images are drawn over a
+// checkerboard, so their transparency is visible against any background
+func (p *CatPic) SetAlphaCheckerboard(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.alphaChecker = enabled
+}
+
 func (p *CatPic) SetImage(img image.Image) {
+	p.mu.Lock()
+	convert := p.convertNRGBA
+	p.mu.Unlock()
+
+	hasAlpha := imgutil.HasAlpha(img)
+
+	// do the conversion without holding the lock so Draw isn't blocked
+	var imgOp paint.ImageOp
+	hasImgOp := false
+	if convert && img != nil {
+		img = imgutil.ToNRGBA(img)
+		imgOp = paint.NewImageOp(img)
+		hasImgOp = true
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.img = img
+	p.imgOp = imgOp
+	p.hasImgOp = hasImgOp
+	p.hasAlpha = hasAlpha
 }
 
 func (p *CatPic) SetLoading() {
@@ -51,15 +106,70 @@ func (p *CatPic) ClearLoading() {
 	p.isLoading = false
 }
 
+// imageOp returns the op to paint, building one if none was prepared
+func (p *CatPic) imageOp() (paint.ImageOp, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.img == nil {
+		return paint.ImageOp{}, false
+	}
+	if p.hasImgOp {
+		return p.imgOp, true
+	}
+	return paint.NewImageOp(p.img), true
+}
+
+// underAlpha reports whether a checkerboard should go under the current image
+func (p *CatPic) underAlpha() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.alphaChecker && p.hasAlpha
+}
+
 func (p *CatPic) Draw(gtx layout.Context) layout.Dimensions {
-	img := p.GetImage()
-	if img == nil {
-		return layout.Dimensions{Size: gtx.Constraints.Min}
+	imgOp, ok := p.imageOp()
+	bg := p.GetBackground()
+	checkered := p.underAlpha()
+
+	// without a background the widget only takes up the space of the image
+	if bg.Kind == BackgroundNone {
+		if !ok {
+			return layout.Dimensions{Size: gtx.Constraints.Min}
+		}
+		return p.layoutImage(gtx, imgOp, checkered)
 	}
 
-	return widget.Image{
-		Src:      paint.NewImageOp(img),
+	// with a background the whole area is filled and the image centered in it
+	size := gtx.Constraints.Max
+	p.drawBackground(gtx, bg, size)
+	if ok {
+		layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return p.layoutImage(gtx, imgOp, checkered)
+		})
+	}
+
+	return layout.Dimensions{Size: size}
+}
+
+// layoutImage fits the image into the constraints, optionally over a
+// checkerboard covering exactly the image's bounds
+func (p *CatPic) layoutImage(gtx layout.Context, imgOp paint.ImageOp, checkered bool) layout.Dimensions {
+	img := widget.Image{
+		Src:      imgOp,
 		Fit:      widget.Contain,
 		Position: layout.Center,
-	}.Layout(gtx)
+	}
+	if !checkered {
+		return img.Layout(gtx)
+	}
+
+	// the image size is only known after layout, so record it and replay it over the checkerboard
+	macro := op.Record(gtx.Ops)
+	dims := img.Layout(gtx)
+	call := macro.Stop()
+
+	p.drawBackground(gtx, CheckerboardBackground(), dims.Size)
+	call.Add(gtx.Ops)
+
+	return dims
 }
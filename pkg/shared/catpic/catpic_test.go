@@ -93,6 +93,57 @@ func TestCatPic_SetImage(t *testing.T) {
 	})
 }
 
+// TestCatPic_SetConvertNRGBA tests converting images to NRGBA when they are set
+func TestCatPic_SetConvertNRGBA(t *testing.T) {
+	ycbcr := image.NewYCbCr(image.Rect(0, 0, 40, 30), image.YCbCrSubsampleRatio420)
+
+	t.Run("disabled_keeps_original", func(t *testing.T) {
+		catPic := NewCatImage(nil)
+		catPic.SetImage(ycbcr)
+
+		_, isYCbCr := catPic.GetImage().(*image.YCbCr)
+		testutil.AssertTrue(t, isYCbCr, "image should not be converted")
+	})
+
+	t.Run("enabled_converts", func(t *testing.T) {
+		catPic := NewCatImage(nil)
+		catPic.SetConvertNRGBA(true)
+		catPic.SetImage(ycbcr)
+
+		converted, isNRGBA := catPic.GetImage().(*image.NRGBA)
+		testutil.AssertTrue(t, isNRGBA, "image should be converted to NRGBA")
+		testutil.AssertImageDimensions(t, converted, 40, 30)
+	})
+
+	t.Run("enabled_draws", func(t *testing.T) {
+		catPic := NewCatImage(nil)
+		catPic.SetConvertNRGBA(true)
+		catPic.SetImage(ycbcr)
+
+		var ops op.Ops
+		gtx := layout.Context{
+			Ops: &ops,
+			Constraints: layout.Constraints{
+				Min: image.Pt(0, 0),
+				Max: image.Pt(400, 300),
+			},
+		}
+
+		dims := catPic.Draw(gtx)
+		testutil.AssertTrue(t, dims.Size.X > 0, "width should be positive")
+		testutil.AssertTrue(t, dims.Size.Y > 0, "height should be positive")
+		testutil.AssertTrue(t, dims.Size.X <= 400, "width should not exceed max")
+		testutil.AssertTrue(t, dims.Size.Y <= 300, "height should not exceed max")
+	})
+
+	t.Run("enabled_nil_image", func(t *testing.T) {
+		catPic := NewCatImage(nil)
+		catPic.SetConvertNRGBA(true)
+		catPic.SetImage(nil)
+		testutil.AssertNil(t, catPic.GetImage(), "nil should stay nil")
+	})
+}
+
 // TestCatPic_IsLoading tests the IsLoading method
 func TestCatPic_IsLoading(t *testing.T) {
 	t.Run("initially_false", func(t *testing.T) {
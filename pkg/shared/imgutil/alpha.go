@@ -0,0 +1,42 @@
+package imgutil
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// HasAlpha reports whether any pixel of img is not fully opaque. Most image
+// types answer this via their Opaque method, others are scanned.
+func HasAlpha(img image.Image) bool {
+	if img == nil {
+		return false
+	}
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Flatten composites img over a solid background, returning an opaque copy
+// with bounds at the origin. The background's own alpha is ignored.
+func Flatten(img image.Image, bg color.Color) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+
+	opaque := color.NRGBAModel.Convert(bg).(color.NRGBA)
+	opaque.A = 255
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(opaque), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+
+	return dst
+}
@@ -0,0 +1,53 @@
+package imgutil
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestHasAlpha tests transparency detection
+func TestHasAlpha(t *testing.T) {
+	transparent := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	transparent.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want bool
+	}{
+		{name: "nil", img: nil, want: false},
+		{name: "opaque_rgba", img: testutil.CreateColorImage(4, 4, 1, 2, 3), want: false},
+		{name: "ycbcr_is_opaque", img: image.NewYCbCr(image.Rect(0, 0, 2, 2), image.YCbCrSubsampleRatio444), want: false},
+		{name: "transparent_nrgba", img: transparent, want: true},
+		{name: "scanned_type", img: opaqueWrapper{testutil.CreateColorImage(2, 2, 9, 9, 9)}, want: false},
+		{name: "scanned_transparent_type", img: opaqueWrapper{transparent}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, HasAlpha(tt.img), "HasAlpha")
+		})
+	}
+}
+
+// TestFlatten tests compositing onto a background color
+func TestFlatten(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(3, 3, 5, 4))
+	src.SetNRGBA(3, 3, color.NRGBA{R: 255, A: 255})
+	src.SetNRGBA(4, 3, color.NRGBA{R: 255, A: 0})
+
+	dst := Flatten(src, color.NRGBA{B: 255, A: 10})
+
+	testutil.AssertEqual(t, image.Rect(0, 0, 2, 1), dst.Bounds(), "bounds rebased")
+	testutil.AssertFalse(t, HasAlpha(dst), "flattened image should be opaque")
+	testutil.AssertEqual(t, color.NRGBA{R: 255, A: 255}, dst.NRGBAAt(0, 0), "opaque pixel kept")
+	testutil.AssertEqual(t, color.NRGBA{B: 255, A: 255}, dst.NRGBAAt(1, 0), "transparent pixel shows background")
+}
+
+// opaqueWrapper hides the Opaque method so HasAlpha has to scan
+type opaqueWrapper struct {
+	image.Image
+}
@@ -0,0 +1,79 @@
+package imgutil
+
+import (
+	"image"
+	"image/draw"
+)
+
+// BoxBlur applies a single box blur of the given radius to img. It runs a
+// horizontal then a vertical pass with a sliding window sum, so the cost per
+// pixel doesn't depend on the radius. Pixels past the edges repeat the edge.
+// The result is premultiplied, so transparent areas don't leak color.
+func BoxBlur(img image.Image, radius int) *image.RGBA {
+	src := toRGBA(img)
+	if radius <= 0 || src.Rect.Empty() {
+		return src
+	}
+
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	tmp := image.NewRGBA(src.Rect)
+	dst := image.NewRGBA(src.Rect)
+
+	// horizontal: each row is a line of w pixels, 4 bytes apart
+	for y := 0; y < h; y++ {
+		off := y * src.Stride
+		blurLine(src.Pix[off:], tmp.Pix[off:], w, 4, radius)
+	}
+	// vertical: each column is a line of h pixels, one stride apart
+	for x := 0; x < w; x++ {
+		off := x * 4
+		blurLine(tmp.Pix[off:], dst.Pix[off:], h, src.Stride, radius)
+	}
+
+	return dst
+}
+
+// Blur approximates a gaussian blur with three box blur passes
+func Blur(img image.Image, radius int) *image.RGBA {
+	out := BoxBlur(img, radius)
+	out = BoxBlur(out, radius)
+	return BoxBlur(out, radius)
+}
+
+// blurLine box-blurs n RGBA pixels spaced step bytes apart from src into dst
+func blurLine(src, dst []uint8, n, step, radius int) {
+	window := uint32(2*radius + 1)
+	clamp := func(i int) int {
+		return min(max(i, 0), n-1) * step
+	}
+
+	var sum [4]uint32
+	// prime the window centered on the first pixel
+	for i := -radius; i <= radius; i++ {
+		p := clamp(i)
+		for c := 0; c < 4; c++ {
+			sum[c] += uint32(src[p+c])
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		o := i * step
+		for c := 0; c < 4; c++ {
+			dst[o+c] = uint8(sum[c] / window)
+		}
+		// slide: drop the leftmost pixel and add the next one on the right
+		out := clamp(i - radius)
+		in := clamp(i + radius + 1)
+		for c := 0; c < 4; c++ {
+			sum[c] += uint32(src[in+c]) - uint32(src[out+c])
+		}
+	}
+}
+
+// toRGBA returns a premultiplied copy of img with bounds at the origin
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,69 @@
+package imgutil
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestBoxBlur tests single-pass box blurring
+func TestBoxBlur(t *testing.T) {
+	t.Run("solid_color_unchanged", func(t *testing.T) {
+		src := testutil.CreateColorImage(20, 10, 30, 60, 90)
+		dst := BoxBlur(src, 3)
+		testutil.AssertTrue(t, testutil.ImagesEqual(src, dst), "blurring a solid color should not change it")
+	})
+
+	t.Run("zero_radius_copies", func(t *testing.T) {
+		src := testutil.CreateGradientImage(16, 4)
+		dst := BoxBlur(src, 0)
+		testutil.AssertTrue(t, testutil.ImagesEqual(src, dst), "zero radius should copy")
+	})
+
+	t.Run("spreads_single_pixel", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(0, 0, 5, 5))
+		src.SetRGBA(2, 2, color.RGBA{R: 225, G: 225, B: 225, A: 225})
+		dst := BoxBlur(src, 1)
+
+		// a 3x3 window spreads the pixel evenly across 9 pixels
+		testutil.AssertEqual(t, color.RGBA{R: 25, G: 25, B: 25, A: 25}, dst.RGBAAt(2, 2), "center")
+		testutil.AssertEqual(t, color.RGBA{R: 25, G: 25, B: 25, A: 25}, dst.RGBAAt(1, 1), "corner of window")
+		testutil.AssertEqual(t, color.RGBA{}, dst.RGBAAt(0, 0), "outside window")
+	})
+
+	t.Run("radius_larger_than_image", func(t *testing.T) {
+		src := testutil.CreateGradientImage(4, 2)
+		testutil.AssertNoPanic(t, func() {
+			dst := BoxBlur(src, 50)
+			testutil.AssertImageDimensions(t, dst, 4, 2)
+		}, "large radius should clamp at edges")
+	})
+
+	t.Run("offset_bounds", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(10, 10, 20, 15))
+		dst := BoxBlur(src, 2)
+		testutil.AssertEqual(t, image.Rect(0, 0, 10, 5), dst.Bounds(), "bounds rebased to origin")
+	})
+
+	t.Run("empty_image", func(t *testing.T) {
+		dst := BoxBlur(image.NewRGBA(image.Rect(0, 0, 0, 0)), 2)
+		testutil.AssertTrue(t, dst.Bounds().Empty(), "empty stays empty")
+	})
+}
+
+// TestBlur tests the multi-pass blur
+func TestBlur(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 9, 1))
+	src.SetRGBA(4, 0, color.RGBA{R: 255, A: 255})
+	dst := Blur(src, 1)
+
+	// the peak stays in the middle and falls off towards the edges
+	center := dst.RGBAAt(4, 0).R
+	near := dst.RGBAAt(3, 0).R
+	far := dst.RGBAAt(1, 0).R
+	testutil.AssertTrue(t, center > near, "center brighter than neighbour")
+	testutil.AssertTrue(t, near > far, "neighbour brighter than far pixel")
+	testutil.AssertEqual(t, dst.RGBAAt(3, 0), dst.RGBAAt(5, 0), "symmetric")
+}
@@ -0,0 +1,62 @@
+package imgutil
+
+import (
+	"image"
+)
+
+// Histogram counts pixels per 8-bit level for each channel and for luminance.
+// Fully transparent pixels are not counted.
+type Histogram struct {
+	Red   [256]uint32
+	Green [256]uint32
+	Blue  [256]uint32
+	Luma  [256]uint32
+}
+
+// ComputeHistogram builds the histogram of img
+func ComputeHistogram(img image.Image) Histogram {
+	var h Histogram
+	if img == nil {
+		return h
+	}
+
+	src := ToNRGBA(img)
+	w, ht := src.Rect.Dx(), src.Rect.Dy()
+	for y := 0; y < ht; y++ {
+		row := src.Pix[y*src.Stride : y*src.Stride+w*4]
+		for x := 0; x < len(row); x += 4 {
+			if row[x+3] == 0 {
+				continue
+			}
+			r, g, b := row[x], row[x+1], row[x+2]
+			h.Red[r]++
+			h.Green[g]++
+			h.Blue[b]++
+			// Rec. 601 luma weights
+			h.Luma[(299*uint32(r)+587*uint32(g)+114*uint32(b))/1000]++
+		}
+	}
+
+	return h
+}
+
+// Peak returns the largest bin count across all channels, useful for scaling a plot
+func (h *Histogram) Peak() uint32 {
+	var peak uint32
+	for _, bins := range []*[256]uint32{&h.Red, &h.Green, &h.Blue, &h.Luma} {
+		for _, v := range bins {
+			peak = max(peak, v)
+		}
+	}
+	return peak
+}
+
+// BitDepth returns the bits per channel of img's pixel format
+func BitDepth(img image.Image) int {
+	switch img.(type) {
+	case *image.RGBA64, *image.NRGBA64, *image.Gray16, *image.Alpha16:
+		return 16
+	default:
+		return 8
+	}
+}
@@ -0,0 +1,54 @@
+package imgutil
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestComputeHistogram tests per-channel and luminance counting
+func TestComputeHistogram(t *testing.T) {
+	t.Run("solid_color", func(t *testing.T) {
+		h := ComputeHistogram(testutil.CreateColorImage(4, 5, 255, 0, 100))
+		testutil.AssertEqual(t, uint32(20), h.Red[255], "red bin")
+		testutil.AssertEqual(t, uint32(20), h.Green[0], "green bin")
+		testutil.AssertEqual(t, uint32(20), h.Blue[100], "blue bin")
+		// 0.299*255 + 0.114*100 = 87.6
+		testutil.AssertEqual(t, uint32(20), h.Luma[87], "luma bin")
+		testutil.AssertEqual(t, uint32(20), h.Peak(), "peak")
+	})
+
+	t.Run("skips_transparent_pixels", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+		img.SetNRGBA(0, 0, color.NRGBA{R: 10, A: 255})
+		h := ComputeHistogram(img)
+		testutil.AssertEqual(t, uint32(1), h.Red[10], "opaque pixel counted")
+		testutil.AssertEqual(t, uint32(0), h.Red[0], "transparent pixel skipped")
+	})
+
+	t.Run("nil_image", func(t *testing.T) {
+		h := ComputeHistogram(nil)
+		testutil.AssertEqual(t, uint32(0), h.Peak(), "empty histogram")
+	})
+
+	t.Run("gray_luma_matches_level", func(t *testing.T) {
+		img := image.NewGray(image.Rect(0, 0, 3, 3))
+		for i := range img.Pix {
+			img.Pix[i] = 200
+		}
+		h := ComputeHistogram(img)
+		testutil.AssertEqual(t, uint32(9), h.Luma[200], "gray luma")
+	})
+}
+
+// TestBitDepth tests bit depth detection
+func TestBitDepth(t *testing.T) {
+	rect := image.Rect(0, 0, 1, 1)
+	testutil.AssertEqual(t, 8, BitDepth(image.NewRGBA(rect)), "RGBA")
+	testutil.AssertEqual(t, 8, BitDepth(image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)), "YCbCr")
+	testutil.AssertEqual(t, 8, BitDepth(image.NewPaletted(rect, color.Palette{color.Black})), "paletted")
+	testutil.AssertEqual(t, 16, BitDepth(image.NewNRGBA64(rect)), "NRGBA64")
+	testutil.AssertEqual(t, 16, BitDepth(image.NewGray16(rect)), "Gray16")
+}
@@ -0,0 +1,128 @@
+package imgutil
+
+import (
+	"image"
+	"image/color"
+)
+
+// dominantSamples is roughly how many pixels per side DominantColor looks at
+const dominantSamples = 256
+
+// DominantColor returns the average of the most common coarse color in img,
+// with colors bucketed by their top 3 bits per channel. Large images are
+// sampled on a grid and transparent pixels are skipped. A nil or fully
+// transparent image gives the zero color.
+func DominantColor(img image.Image) color.NRGBA {
+	if img == nil {
+		return color.NRGBA{}
+	}
+
+	type bucket struct {
+		n       uint32
+		r, g, b uint32
+	}
+	var buckets [512]bucket
+
+	src := ToNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	step := max(max(w, h)/dominantSamples, 1)
+	for y := 0; y < h; y += step {
+		row := src.Pix[y*src.Stride:]
+		for x := 0; x < w; x += step {
+			p := row[x*4 : x*4+4]
+			if p[3] == 0 {
+				continue
+			}
+			bk := &buckets[int(p[0]>>5)<<6|int(p[1]>>5)<<3|int(p[2]>>5)]
+			bk.n++
+			bk.r += uint32(p[0])
+			bk.g += uint32(p[1])
+			bk.b += uint32(p[2])
+		}
+	}
+
+	best := &buckets[0]
+	for i := range buckets {
+		if buckets[i].n > best.n {
+			best = &buckets[i]
+		}
+	}
+	if best.n == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{
+		R: uint8(best.r / best.n),
+		G: uint8(best.g / best.n),
+		B: uint8(best.b / best.n),
+		A: 255,
+	}
+}
+
+// ColorName names c with a basic color word such as "orange" or "gray",
+// going by its hue, saturation and lightness
+func ColorName(c color.NRGBA) string {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi, lo := max(r, g, b), min(r, g, b)
+	l := (hi + lo) / 2
+	d := hi - lo
+
+	var s float64
+	if d > 0 {
+		s = d / (1 - abs(2*l-1))
+	}
+	// saturation exaggerates tiny tints near black and white, so the chroma
+	// has to be noticeable as well
+	switch {
+	case l < 0.12:
+		return "black"
+	case l > 0.92:
+		return "white"
+	case s < 0.2 || d < 0.08:
+		if l > 0.8 {
+			return "white"
+		}
+		return "gray"
+	}
+
+	var hue float64
+	switch hi {
+	case r:
+		hue = 60 * (g - b) / d
+	case g:
+		hue = 60 * ((b-r)/d + 2)
+	default:
+		hue = 60 * ((r-g)/d + 4)
+	}
+	if hue < 0 {
+		hue += 360
+	}
+
+	switch {
+	case hue < 45 && l < 0.4:
+		// dark reds and oranges read as brown
+		return "brown"
+	case hue < 15 || hue >= 345:
+		return "red"
+	case hue < 45:
+		return "orange"
+	case hue < 70:
+		return "yellow"
+	case hue < 160:
+		return "green"
+	case hue < 200:
+		return "cyan"
+	case hue < 260:
+		return "blue"
+	case hue < 290:
+		return "purple"
+	default:
+		return "pink"
+	}
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,64 @@
+package imgutil
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestDominantColor tests the most common color wins over a minority
+func TestDominantColor(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			c := color.NRGBA{R: 200, G: 120, B: 50, A: 255}
+			if x < 3 {
+				c = color.NRGBA{R: 20, G: 20, B: 200, A: 255}
+			}
+			if y == 0 {
+				// transparent pixels don't count however many there are
+				c = color.NRGBA{}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	testutil.AssertEqual(t, color.NRGBA{R: 200, G: 120, B: 50, A: 255}, DominantColor(img), "dominant color")
+	testutil.AssertEqual(t, color.NRGBA{}, DominantColor(nil), "nil image")
+	testutil.AssertEqual(t, color.NRGBA{}, DominantColor(image.NewNRGBA(image.Rect(0, 0, 2, 2))), "transparent image")
+}
+
+// TestDominantColor_Sampled tests large images are sampled
+func TestDominantColor_Sampled(t *testing.T) {
+	img := testutil.CreateColorImage(1000, 600, 10, 200, 10)
+	testutil.AssertEqual(t, color.NRGBA{R: 10, G: 200, B: 10, A: 255}, DominantColor(img), "dominant color")
+}
+
+// TestColorName tests naming colors
+func TestColorName(t *testing.T) {
+	tests := []struct {
+		c    color.NRGBA
+		want string
+	}{
+		{color.NRGBA{R: 0, G: 0, B: 0}, "black"},
+		{color.NRGBA{R: 250, G: 250, B: 245}, "white"},
+		{color.NRGBA{R: 128, G: 128, B: 130}, "gray"},
+		{color.NRGBA{R: 220, G: 30, B: 30}, "red"},
+		{color.NRGBA{R: 200, G: 120, B: 50}, "orange"},
+		{color.NRGBA{R: 110, G: 70, B: 40}, "brown"},
+		{color.NRGBA{R: 230, G: 210, B: 40}, "yellow"},
+		{color.NRGBA{R: 40, G: 180, B: 60}, "green"},
+		{color.NRGBA{R: 40, G: 200, B: 210}, "cyan"},
+		{color.NRGBA{R: 40, G: 60, B: 200}, "blue"},
+		{color.NRGBA{R: 140, G: 50, B: 200}, "purple"},
+		{color.NRGBA{R: 240, G: 100, B: 180}, "pink"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, ColorName(tt.c), "color name")
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package imgutil
+
+import (
+	"image"
+	"image/color"
+)
+
+// Checkerboard returns a cols x rows image with one pixel per square,
+// alternating between a and b starting with a in the top left. Scale it up
+// with nearest-neighbour filtering to draw a checkerboard of any cell size.
+func Checkerboard(cols, rows int, a, b color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, max(cols, 0), max(rows, 0)))
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			if (x+y)%2 == 0 {
+				img.SetNRGBA(x, y, a)
+			} else {
+				img.SetNRGBA(x, y, b)
+			}
+		}
+	}
+	return img
+}
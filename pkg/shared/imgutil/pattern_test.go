@@ -0,0 +1,28 @@
+package imgutil
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestCheckerboard tests checkerboard generation
+func TestCheckerboard(t *testing.T) {
+	light := color.NRGBA{R: 200, G: 200, B: 200, A: 255}
+	dark := color.NRGBA{R: 100, G: 100, B: 100, A: 255}
+
+	t.Run("alternates_cells", func(t *testing.T) {
+		img := Checkerboard(3, 2, light, dark)
+		testutil.AssertImageDimensions(t, img, 3, 2)
+		testutil.AssertEqual(t, light, img.NRGBAAt(0, 0), "top left")
+		testutil.AssertEqual(t, dark, img.NRGBAAt(1, 0), "second in first row")
+		testutil.AssertEqual(t, dark, img.NRGBAAt(0, 1), "first in second row")
+		testutil.AssertEqual(t, light, img.NRGBAAt(1, 1), "diagonal")
+	})
+
+	t.Run("empty_size", func(t *testing.T) {
+		img := Checkerboard(0, -1, light, dark)
+		testutil.AssertTrue(t, img.Bounds().Empty(), "should be empty")
+	})
+}
@@ -0,0 +1,84 @@
+package imgutil
+
+import (
+	"image"
+	"image/draw"
+)
+
+// FitSize returns the largest size with the same aspect ratio as (w, h) that
+// fits within (maxW, maxH). Sizes already within bounds are returned unchanged.
+func FitSize(w, h, maxW, maxH int) (int, int) {
+	if w <= 0 || h <= 0 || maxW <= 0 || maxH <= 0 {
+		return w, h
+	}
+	if w <= maxW && h <= maxH {
+		return w, h
+	}
+
+	// pick the axis that needs the most shrinking
+	if w*maxH > h*maxW {
+		return maxW, max(1, h*maxW/w)
+	}
+	return max(1, w*maxH/h), maxH
+}
+
+// ToNRGBA returns img as an *image.NRGBA with bounds starting at the origin,
+// converting only when necessary
+func ToNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// Downscale shrinks img to fit within (maxW, maxH) using an area-averaging box
+// filter. Images that already fit are returned as is.
+func Downscale(img image.Image, maxW, maxH int) image.Image {
+	b := img.Bounds()
+	dw, dh := FitSize(b.Dx(), b.Dy(), maxW, maxH)
+	if dw == b.Dx() && dh == b.Dy() {
+		return img
+	}
+
+	src := ToNRGBA(img)
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for dy := 0; dy < dh; dy++ {
+		// source rows covered by this destination row
+		y0 := dy * sh / dh
+		y1 := max(y0+1, (dy+1)*sh/dh)
+		for dx := 0; dx < dw; dx++ {
+			x0 := dx * sw / dw
+			x1 := max(x0+1, (dx+1)*sw/dw)
+
+			// average premultiplied values so transparent pixels don't bleed color
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					pa := uint64(p[3])
+					r += uint64(p[0]) * pa
+					g += uint64(p[1]) * pa
+					bl += uint64(p[2]) * pa
+					a += pa
+					n++
+				}
+			}
+
+			o := dst.PixOffset(dx, dy)
+			if a > 0 {
+				dst.Pix[o+0] = uint8(r / a)
+				dst.Pix[o+1] = uint8(g / a)
+				dst.Pix[o+2] = uint8(bl / a)
+			}
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,92 @@
+package imgutil
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestFitSize tests aspect-preserving fit calculations
+func TestFitSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		w, h       int
+		maxW, maxH int
+		wantW      int
+		wantH      int
+	}{
+		{name: "already_fits", w: 100, h: 50, maxW: 200, maxH: 200, wantW: 100, wantH: 50},
+		{name: "too_wide", w: 400, h: 100, maxW: 200, maxH: 200, wantW: 200, wantH: 50},
+		{name: "too_tall", w: 100, h: 400, maxW: 200, maxH: 200, wantW: 50, wantH: 200},
+		{name: "both_too_big", w: 1000, h: 800, maxW: 500, maxH: 500, wantW: 500, wantH: 400},
+		{name: "extreme_ratio_keeps_one_pixel", w: 10000, h: 1, maxW: 100, maxH: 100, wantW: 100, wantH: 1},
+		{name: "zero_bounds_untouched", w: 100, h: 100, maxW: 0, maxH: 0, wantW: 100, wantH: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := FitSize(tt.w, tt.h, tt.maxW, tt.maxH)
+			testutil.AssertEqual(t, tt.wantW, w, "width")
+			testutil.AssertEqual(t, tt.wantH, h, "height")
+		})
+	}
+}
+
+// TestToNRGBA tests conversion to NRGBA
+func TestToNRGBA(t *testing.T) {
+	t.Run("nrgba_passthrough", func(t *testing.T) {
+		src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+		testutil.AssertTrue(t, ToNRGBA(src) == src, "NRGBA at origin should not be copied")
+	})
+
+	t.Run("converts_rgba", func(t *testing.T) {
+		src := testutil.CreateColorImage(4, 3, 10, 20, 30)
+		dst := ToNRGBA(src)
+		testutil.AssertImageDimensions(t, dst, 4, 3)
+		testutil.AssertEqual(t, color.NRGBA{R: 10, G: 20, B: 30, A: 255}, dst.NRGBAAt(2, 1), "pixel")
+	})
+
+	t.Run("rebases_offset_bounds", func(t *testing.T) {
+		src := image.NewNRGBA(image.Rect(5, 5, 9, 9))
+		dst := ToNRGBA(src)
+		testutil.AssertEqual(t, image.Rect(0, 0, 4, 4), dst.Bounds(), "bounds")
+	})
+}
+
+// TestDownscale tests box-filter downscaling
+func TestDownscale(t *testing.T) {
+	t.Run("fits_unchanged", func(t *testing.T) {
+		src := testutil.CreateColorImage(10, 10, 1, 2, 3)
+		testutil.AssertTrue(t, Downscale(src, 20, 20) == image.Image(src), "should return the source")
+	})
+
+	t.Run("preserves_aspect_ratio", func(t *testing.T) {
+		src := testutil.CreateColorImage(400, 200, 50, 100, 150)
+		dst := Downscale(src, 100, 100)
+		testutil.AssertImageDimensions(t, dst, 100, 50)
+		r, g, b, _ := dst.At(50, 25).RGBA()
+		testutil.AssertEqual(t, []uint32{50, 100, 150}, []uint32{r >> 8, g >> 8, b >> 8}, "solid color preserved")
+	})
+
+	t.Run("averages_pixels", func(t *testing.T) {
+		src := image.NewGray(image.Rect(0, 0, 2, 1))
+		src.SetGray(0, 0, color.Gray{Y: 0})
+		src.SetGray(1, 0, color.Gray{Y: 200})
+		dst := Downscale(src, 1, 1)
+		r, _, _, _ := dst.At(0, 0).RGBA()
+		testutil.AssertEqual(t, uint32(100), r>>8, "average of black and gray")
+	})
+
+	t.Run("transparent_pixels_do_not_bleed", func(t *testing.T) {
+		src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+		src.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+		src.SetNRGBA(1, 0, color.NRGBA{B: 255, A: 0})
+		dst := ToNRGBA(Downscale(src, 1, 1))
+		px := dst.NRGBAAt(0, 0)
+		testutil.AssertEqual(t, uint8(255), px.R, "red kept")
+		testutil.AssertEqual(t, uint8(0), px.B, "hidden blue dropped")
+		testutil.AssertEqual(t, uint8(127), px.A, "alpha averaged")
+	})
+}
@@ -0,0 +1,58 @@
+package openext
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrAction = errors.New("invalid action")
+
+type ActionKind int
+
+const (
+	ActionNone   ActionKind = iota // nothing happens to the file
+	ActionReveal                   // the file is shown in the file manager
+	ActionOpen                     // the file is opened with its default application
+	ActionRun                      // Command is run on the file
+)
+
+// Action is what to do with a file once it's saved. The zero Action does
+// nothing.
+type Action struct {
+	Kind    ActionKind
+	Command string // for ActionRun, see Run
+}
+
+// ParseAction reads an action given as none, reveal, open or run:<command>,
+// such as "run:gimp {path}"
+func ParseAction(s string) (Action, error) {
+	kind, arg, hasArg := strings.Cut(strings.TrimSpace(s), ":")
+	switch kind = strings.ToLower(kind); {
+	case kind == "run" && strings.TrimSpace(arg) != "":
+		return Action{Kind: ActionRun, Command: arg}, nil
+	case hasArg:
+		// only run takes an argument, and needs one
+	case kind == "" || kind == "none":
+		return Action{}, nil
+	case kind == "reveal":
+		return Action{Kind: ActionReveal}, nil
+	case kind == "open":
+		return Action{Kind: ActionOpen}, nil
+	}
+	return Action{}, fmt.Errorf("%w %q, want none, reveal, open or run:<command>", ErrAction, s)
+}
+
+// Do applies the action to path, without waiting for what it starts
+func (a Action) Do(path string) error {
+	switch a.Kind {
+	case ActionReveal:
+		return Reveal(path)
+	case ActionOpen:
+		return Open(path)
+	case ActionRun:
+		return Run(a.Command, path)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+package openext
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestParseAction tests reading actions from flags
+func TestParseAction(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Action
+		wantErr bool
+	}{
+		{"", Action{}, false},
+		{"none", Action{}, false},
+		{"Reveal", Action{Kind: ActionReveal}, false},
+		{"open", Action{Kind: ActionOpen}, false},
+		{"run:gimp {path}", Action{Kind: ActionRun, Command: "gimp {path}"}, false},
+		{"run:", Action{}, true},
+		{"run", Action{}, true},
+		{"open:gimp", Action{}, true},
+		{"print", Action{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAction(tt.in)
+			if tt.wantErr {
+				testutil.AssertTrue(t, errors.Is(err, ErrAction), "should be ErrAction")
+				return
+			}
+			testutil.AssertNoError(t, err, "ParseAction should succeed")
+			testutil.AssertEqual(t, tt.want, got, "action")
+		})
+	}
+}
+
+// TestAction_Do tests the chosen action is applied to the file, and none
+// leaves it alone
+func TestAction_Do(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs touch")
+	}
+	dir := t.TempDir()
+
+	testutil.AssertNoError(t, Action{}.Do(filepath.Join(dir, "none.png")), "none should succeed")
+
+	path := filepath.Join(dir, "cat.png")
+	testutil.AssertNoError(t, Action{Kind: ActionRun, Command: "touch"}.Do(path), "run should succeed")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("command should have created the file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "none.png"))
+	testutil.AssertTrue(t, errors.Is(err, os.ErrNotExist), "none does nothing")
+}
@@ -0,0 +1,79 @@
+package openext
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PathPlaceholder in a configured command is replaced by the file path
+const PathPlaceholder = "{path}"
+
+var ErrNoCommand = errors.New("no command configured")
+
+// Open opens path with the default application for its type
+func Open(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	return start(openCmd(abs))
+}
+
+// Reveal shows path in the file manager, selected where the platform
+// supports it and otherwise by opening the folder holding it
+func Reveal(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	return start(revealCmd(abs))
+}
+
+// Run starts a user configured command such as "gimp {path}". The command
+// is split on spaces without shell quoting, each {path} is replaced by the
+// file path, and the path is appended when there's no placeholder.
+func Run(command, path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	args := commandArgs(command, abs)
+	if len(args) == 0 {
+		return ErrNoCommand
+	}
+	return start(exec.Command(args[0], args[1:]...))
+}
+
+// commandArgs splits command and fills in path
+func commandArgs(command, path string) []string {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	replaced := false
+	for i, arg := range args {
+		if strings.Contains(arg, PathPlaceholder) {
+			args[i] = strings.ReplaceAll(arg, PathPlaceholder, path)
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, path)
+	}
+	return args
+}
+
+// start runs cmd without waiting for it, the opened application may stay up
+// long after the save. The process is reaped in the background.
+func start(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", filepath.Base(cmd.Path), err)
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+	return nil
+}
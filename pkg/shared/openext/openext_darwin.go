@@ -0,0 +1,12 @@
+package openext
+
+import "os/exec"
+
+func openCmd(path string) *exec.Cmd {
+	return exec.Command("open", path)
+}
+
+// revealCmd selects path in Finder
+func revealCmd(path string) *exec.Cmd {
+	return exec.Command("open", "-R", path)
+}
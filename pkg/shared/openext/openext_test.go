@@ -0,0 +1,75 @@
+package openext
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestCommandArgs tests filling in the path of a configured command
+func TestCommandArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"appended", "gimp", []string{"gimp", "/tmp/cat.png"}},
+		{"placeholder", "convert {path} -resize 50% out.png", []string{"convert", "/tmp/cat.png", "-resize", "50%", "out.png"}},
+		{"inside_arg", "viewer --file={path}", []string{"viewer", "--file=/tmp/cat.png"}},
+		{"empty", "   ", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := commandArgs(tt.command, "/tmp/cat.png")
+			testutil.AssertEqual(t, strings.Join(tt.want, "|"), strings.Join(got, "|"), "args")
+		})
+	}
+}
+
+// TestRun tests the configured command is started with the path
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs touch")
+	}
+	path := filepath.Join(t.TempDir(), "cat.png")
+
+	err := Run("touch", path)
+	testutil.AssertNoError(t, err, "Run should succeed")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("command should have created the file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRun_Errors tests missing and unknown commands
+func TestRun_Errors(t *testing.T) {
+	err := Run("", "cat.png")
+	testutil.AssertTrue(t, errors.Is(err, ErrNoCommand), "should be ErrNoCommand")
+
+	err = Run("catfetch-no-such-command", "cat.png")
+	testutil.AssertError(t, err, "unknown command should fail")
+}
+
+// TestPlatformCmds tests the platform commands get an absolute path
+func TestPlatformCmds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cat.png")
+	open := openCmd(path)
+	testutil.AssertEqual(t, path, open.Args[len(open.Args)-1], "open path")
+
+	reveal := revealCmd(path)
+	testutil.AssertTrue(t, len(reveal.Args) > 0, "reveal command")
+}
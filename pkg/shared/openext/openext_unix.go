@@ -0,0 +1,18 @@
+//go:build !darwin && !windows
+
+package openext
+
+import (
+	"os/exec"
+	"path/filepath"
+)
+
+func openCmd(path string) *exec.Cmd {
+	return exec.Command("xdg-open", path)
+}
+
+// revealCmd opens the folder holding path, there's no portable way to ask
+// Linux and BSD file managers to select a file
+func revealCmd(path string) *exec.Cmd {
+	return exec.Command("xdg-open", filepath.Dir(path))
+}
@@ -0,0 +1,19 @@
+package openext
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+func openCmd(path string) *exec.Cmd {
+	return exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+}
+
+// revealCmd selects path in Explorer. Explorer parses its own command line
+// and doesn't understand /select with the path quoted separately, so the
+// line is built by hand.
+func revealCmd(path string) *exec.Cmd {
+	cmd := exec.Command("explorer")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `explorer /select,"` + path + `"`}
+	return cmd
+}
@@ -0,0 +1,22 @@
+package power
+
+import "errors"
+
+var ErrUnsupported = errors.New("power state not supported on this platform")
+
+// State is what the machine reports about its power source
+type State struct {
+	OnBattery  bool // running on battery, not plugged in
+	PowerSaver bool // the power saver profile is selected
+}
+
+// Saving reports whether the app should hold back on background work
+func (s State) Saving() bool {
+	return s.OnBattery || s.PowerSaver
+}
+
+// Read returns the current power state, or ErrUnsupported where it can't be
+// read
+func Read() (State, error) {
+	return read()
+}
@@ -0,0 +1,59 @@
+package power
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sysRoot is where sysfs is mounted
+const sysRoot = "/sys"
+
+func read() (State, error) {
+	return readSysfs(sysRoot)
+}
+
+// readSysfs reads the power supplies and ACPI platform profile under root.
+// A machine without batteries, such as a desktop, is never on battery.
+func readSysfs(root string) (State, error) {
+	supplies, err := os.ReadDir(filepath.Join(root, "class", "power_supply"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return State{}, err
+	}
+
+	var pluggedIn, discharging bool
+	for _, supply := range supplies {
+		dir := filepath.Join(root, "class", "power_supply", supply.Name())
+		switch sysfsValue(filepath.Join(dir, "type")) {
+		case "Mains", "USB":
+			if sysfsValue(filepath.Join(dir, "online")) == "1" {
+				pluggedIn = true
+			}
+		case "Battery":
+			// batteries of mice and keyboards don't power the machine
+			if sysfsValue(filepath.Join(dir, "scope")) == "Device" {
+				continue
+			}
+			if sysfsValue(filepath.Join(dir, "status")) == "Discharging" {
+				discharging = true
+			}
+		}
+	}
+
+	return State{
+		OnBattery:  discharging && !pluggedIn,
+		PowerSaver: sysfsValue(filepath.Join(root, "firmware", "acpi", "platform_profile")) == "low-power",
+	}, nil
+}
+
+// sysfsValue is the trimmed content of a sysfs attribute, empty if it can't
+// be read
+func sysfsValue(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,69 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// writeSysfs creates the attributes under root, keyed by path relative to it
+func writeSysfs(t *testing.T, root string, attrs map[string]string) {
+	t.Helper()
+	for name, value := range attrs {
+		path := filepath.Join(root, name)
+		testutil.AssertNoError(t, os.MkdirAll(filepath.Dir(path), 0o755), "mkdir")
+		testutil.AssertNoError(t, os.WriteFile(path, []byte(value+"\n"), 0o644), "write attribute")
+	}
+}
+
+// TestReadSysfs tests the power state is read from sysfs
+func TestReadSysfs(t *testing.T) {
+	const supplies = "class/power_supply/"
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		want  State
+	}{
+		{"desktop", map[string]string{}, State{}},
+		{"on_battery", map[string]string{
+			supplies + "AC/type":     "Mains",
+			supplies + "AC/online":   "0",
+			supplies + "BAT0/type":   "Battery",
+			supplies + "BAT0/status": "Discharging",
+		}, State{OnBattery: true}},
+		{"charging", map[string]string{
+			supplies + "AC/type":     "Mains",
+			supplies + "AC/online":   "1",
+			supplies + "BAT0/type":   "Battery",
+			supplies + "BAT0/status": "Charging",
+		}, State{}},
+		{"usb_power", map[string]string{
+			supplies + "ucsi/type":   "USB",
+			supplies + "ucsi/online": "1",
+			supplies + "BAT0/type":   "Battery",
+			supplies + "BAT0/status": "Discharging",
+		}, State{}},
+		{"mouse_battery", map[string]string{
+			supplies + "hid-mouse/type":   "Battery",
+			supplies + "hid-mouse/scope":  "Device",
+			supplies + "hid-mouse/status": "Discharging",
+		}, State{}},
+		{"power_saver", map[string]string{
+			"firmware/acpi/platform_profile": "low-power",
+		}, State{PowerSaver: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeSysfs(t, root, tt.attrs)
+
+			got, err := readSysfs(root)
+			testutil.AssertNoError(t, err, "readSysfs should succeed")
+			testutil.AssertEqual(t, tt.want, got, "state")
+			testutil.AssertEqual(t, tt.want.OnBattery || tt.want.PowerSaver, got.Saving(), "saving")
+		})
+	}
+}
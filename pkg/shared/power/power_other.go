@@ -0,0 +1,7 @@
+//go:build !linux
+
+package power
+
+func read() (State, error) {
+	return State{}, ErrUnsupported
+}
@@ -0,0 +1,50 @@
+package savedialog
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrCancelled   = errors.New("save dialog cancelled")
+	ErrUnsupported = errors.New("no save dialog on this platform")
+)
+
+// Ask shows the platform's save dialog titled title, starting in the folder
+// of defaultPath with its file name filled in, and returns the path picked.
+// The dialog asks before replacing an existing file. It returns
+// ErrCancelled when the dialog is closed without picking a path, and
+// ErrUnsupported when there's no dialog to show, such as on Linux without
+// zenity or kdialog.
+func Ask(title, defaultPath string) (string, error) {
+	abs, err := filepath.Abs(defaultPath)
+	if err != nil {
+		return "", err
+	}
+	cmd, err := dialogCmd(title, abs)
+	if err != nil {
+		return "", err
+	}
+	return run(cmd)
+}
+
+// run waits for the dialog and reads the path picked from its output. The
+// dialogs exit with status 1 when cancelled.
+func run(cmd *exec.Cmd) (string, error) {
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", ErrCancelled
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(cmd.Path), err)
+	}
+	path := strings.TrimRight(string(out), "\r\n")
+	if path == "" {
+		return "", ErrCancelled
+	}
+	return path, nil
+}
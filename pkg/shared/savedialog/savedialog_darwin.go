@@ -0,0 +1,17 @@
+package savedialog
+
+import (
+	"os/exec"
+	"path/filepath"
+)
+
+// dialogCmd asks Finder's save panel through AppleScript. The title, name
+// and folder are passed as arguments so they need no quoting.
+func dialogCmd(title, path string) (*exec.Cmd, error) {
+	return exec.Command("osascript",
+		"-e", "on run argv",
+		"-e", "set picked to choose file name with prompt (item 1 of argv) default name (item 2 of argv) default location (POSIX file (item 3 of argv))",
+		"-e", "return POSIX path of picked",
+		"-e", "end run",
+		title, filepath.Base(path), filepath.Dir(path)), nil
+}
@@ -0,0 +1,20 @@
+//go:build !darwin && !windows
+
+package savedialog
+
+import "os/exec"
+
+// lookPath finds the dialog tools, tests replace it
+var lookPath = exec.LookPath
+
+// dialogCmd uses zenity, which GNOME and most desktops have, or kdialog on
+// KDE
+func dialogCmd(title, path string) (*exec.Cmd, error) {
+	if zenity, err := lookPath("zenity"); err == nil {
+		return exec.Command(zenity, "--file-selection", "--save", "--confirm-overwrite", "--title="+title, "--filename="+path), nil
+	}
+	if kdialog, err := lookPath("kdialog"); err == nil {
+		return exec.Command(kdialog, "--title", title, "--getsavefilename", path), nil
+	}
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,80 @@
+//go:build !darwin && !windows
+
+package savedialog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// fakeTool writes a shell script standing in for a dialog tool, which
+// records its arguments and runs body
+func fakeTool(t *testing.T, body string) (tool, argsFile string) {
+	t.Helper()
+	dir := t.TempDir()
+	tool = filepath.Join(dir, "dialog")
+	argsFile = filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\n" + body + "\n"
+	testutil.AssertNoError(t, os.WriteFile(tool, []byte(script), 0o755), "writing the fake tool")
+	return tool, argsFile
+}
+
+// TestAsk tests the path picked, cancelling and missing tools
+func TestAsk(t *testing.T) {
+	saved := lookPath
+	defer func() { lookPath = saved }()
+
+	tests := []struct {
+		name     string
+		tool     string
+		body     string
+		want     string
+		wantErr  error
+		wantArgs string
+	}{
+		{"zenity", "zenity", "echo /tmp/picked.png", "/tmp/picked.png", nil, "--file-selection --save --confirm-overwrite --title=Save cat --filename=/tmp/cats/abc.png"},
+		{"kdialog", "kdialog", "echo /tmp/picked.png", "/tmp/picked.png", nil, "--title Save cat --getsavefilename /tmp/cats/abc.png"},
+		{"cancelled", "zenity", "exit 1", "", ErrCancelled, ""},
+		{"nothing_picked", "zenity", "true", "", ErrCancelled, ""},
+		{"none", "", "", "", ErrUnsupported, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, argsFile := fakeTool(t, tt.body)
+			lookPath = func(name string) (string, error) {
+				if name == tt.tool {
+					return tool, nil
+				}
+				return "", errors.New("not found")
+			}
+
+			path, err := Ask("Save cat", "/tmp/cats/abc.png")
+			if tt.wantErr != nil {
+				testutil.AssertTrue(t, errors.Is(err, tt.wantErr), "should be "+tt.wantErr.Error())
+				return
+			}
+			testutil.AssertNoError(t, err, "Ask should succeed")
+			testutil.AssertEqual(t, tt.want, path, "path picked")
+			args, err := os.ReadFile(argsFile)
+			testutil.AssertNoError(t, err, "reading the arguments")
+			testutil.AssertEqual(t, tt.wantArgs+"\n", string(args), "arguments")
+		})
+	}
+}
+
+// TestAsk_Failed tests a dialog that fails other than by cancelling
+func TestAsk_Failed(t *testing.T) {
+	saved := lookPath
+	defer func() { lookPath = saved }()
+	tool, _ := fakeTool(t, "exit 5")
+	lookPath = func(string) (string, error) { return tool, nil }
+
+	_, err := Ask("Save cat", "/tmp/cats/abc.png")
+	testutil.AssertError(t, err, "Ask should fail")
+	testutil.AssertTrue(t, !errors.Is(err, ErrCancelled), "failing isn't cancelling")
+}
@@ -0,0 +1,27 @@
+package savedialog
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// dialogScript shows the Windows Forms save dialog, which asks before
+// replacing a file. The title, folder and name come from the environment so
+// they need no quoting.
+const dialogScript = `Add-Type -AssemblyName System.Windows.Forms
+[Console]::OutputEncoding = [Text.Encoding]::UTF8
+$d = New-Object System.Windows.Forms.SaveFileDialog
+$d.Title = $env:SAVEDIALOG_TITLE
+$d.InitialDirectory = $env:SAVEDIALOG_DIR
+$d.FileName = $env:SAVEDIALOG_NAME
+if ($d.ShowDialog() -eq 'OK') { $d.FileName } else { exit 1 }`
+
+func dialogCmd(title, path string) (*exec.Cmd, error) {
+	cmd := exec.Command("powershell", "-NoProfile", "-STA", "-Command", dialogScript)
+	cmd.Env = append(os.Environ(),
+		"SAVEDIALOG_TITLE="+title,
+		"SAVEDIALOG_DIR="+filepath.Dir(path),
+		"SAVEDIALOG_NAME="+filepath.Base(path))
+	return cmd, nil
+}
@@ -0,0 +1,183 @@
+package savename
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultTemplate names saved cats like 2024-05-01_abc123_cute-orange.jpeg
+const DefaultTemplate = "{date}_{id}_{tags}.{ext}"
+
+// maxNameBytes keeps names under the 255 byte limit of common filesystems,
+// with room for a collision suffix
+const maxNameBytes = 200
+
+// maxAttempts bounds the collision suffixes tried by Create
+const maxAttempts = 1000
+
+var (
+	ErrUnknownField  = errors.New("unknown template field")
+	ErrUnclosedField = errors.New("unclosed template field")
+	ErrNoFreeName    = errors.New("no free file name")
+)
+
+// Fields fill the placeholders of a template
+type Fields struct {
+	ID   string
+	Tags []string
+	Ext  string    // without the dot
+	Time time.Time // when the cat was saved or fetched
+}
+
+// Render fills tmpl and returns a name safe to use as a single path
+// element. The placeholders are {id}, {tags} (joined with "-"), {ext},
+// {date} (2006-01-02), {time} (150405) and {unix}. Separators left dangling
+// by empty fields are dropped.
+func Render(tmpl string, f Fields) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			b.WriteString(tmpl)
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: %q", ErrUnclosedField, tmpl[start:])
+		}
+		end += start
+
+		value, err := f.value(tmpl[start+1 : end])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(tmpl[:start])
+		b.WriteString(replaceUnsafe(value))
+		tmpl = tmpl[end+1:]
+	}
+	return Sanitize(tidy(b.String())), nil
+}
+
+func (f Fields) value(field string) (string, error) {
+	switch field {
+	case "id":
+		return f.ID, nil
+	case "tags":
+		return strings.Join(f.Tags, "-"), nil
+	case "ext":
+		return strings.TrimPrefix(f.Ext, "."), nil
+	case "date":
+		return f.Time.Format("2006-01-02"), nil
+	case "time":
+		return f.Time.Format("150405"), nil
+	case "unix":
+		return strconv.FormatInt(f.Time.Unix(), 10), nil
+	default:
+		return "", fmt.Errorf("%w: {%s}", ErrUnknownField, field)
+	}
+}
+
+// tidy collapses runs of separators and drops those next to the extension
+// dot or at the start, which empty fields leave behind
+func tidy(name string) string {
+	var b strings.Builder
+	var last rune
+	for _, r := range name {
+		if isSeparator(r) && (isSeparator(last) || last == 0 || last == '.') {
+			continue
+		}
+		if r == '.' && isSeparator(last) {
+			s := strings.TrimRight(b.String(), "_- ")
+			b.Reset()
+			b.WriteString(s)
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return strings.TrimRight(b.String(), "_- ")
+}
+
+func isSeparator(r rune) bool {
+	return r == '_' || r == '-' || r == ' '
+}
+
+// Sanitize makes name safe as a single path element on every platform:
+// separators, reserved and control characters become "_", leading and
+// trailing dots and spaces are dropped, Windows device names are prefixed,
+// and long names are cut short keeping the extension. An empty result
+// becomes "cat".
+func Sanitize(name string) string {
+	name = strings.Trim(replaceUnsafe(name), ". ")
+
+	if len(name) > maxNameBytes {
+		ext := filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		base := name[:maxNameBytes-len(ext)]
+		// don't split a multi-byte character
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		name = base + ext
+	}
+
+	if isReservedName(name) {
+		name = "_" + name
+	}
+	if name == "" {
+		return "cat"
+	}
+	return name
+}
+
+// replaceUnsafe replaces path separators, characters reserved on Windows,
+// control characters and invalid UTF-8 with "_"
+func replaceUnsafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), strings.ContainsRune(`<>:"/\|?*`, r):
+			return '_'
+		default:
+			return r
+		}
+	}, s)
+}
+
+// isReservedName reports whether Windows treats name as a device, which it
+// does whatever the extension
+func isReservedName(name string) bool {
+	base, _, _ := strings.Cut(name, ".")
+	switch strings.ToUpper(base) {
+	case "CON", "PRN", "AUX", "NUL",
+		"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+		"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9":
+		return true
+	}
+	return false
+}
+
+// Create creates name in dir for writing, adding -1, -2 and so on before
+// the extension while the name is taken. Files are never overwritten.
+func Create(dir, name string) (*os.File, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	candidate := name
+	for i := 1; i <= maxAttempts; i++ {
+		f, err := os.OpenFile(filepath.Join(dir, candidate), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+		candidate = base + "-" + strconv.Itoa(i) + ext
+	}
+	return nil, fmt.Errorf("%w for %s in %s", ErrNoFreeName, name, dir)
+}
@@ -0,0 +1,111 @@
+package savename
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestRender tests filling templates
+func TestRender(t *testing.T) {
+	at := time.Date(2024, 5, 1, 13, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		tmpl   string
+		fields Fields
+		want   string
+	}{
+		{"default", DefaultTemplate, Fields{ID: "abc", Tags: []string{"cute", "orange"}, Ext: "png", Time: at}, "2024-05-01_abc_cute-orange.png"},
+		{"no_tags", DefaultTemplate, Fields{ID: "abc", Ext: "png", Time: at}, "2024-05-01_abc.png"},
+		{"no_id_or_tags", DefaultTemplate, Fields{Ext: "jpeg", Time: at}, "2024-05-01.jpeg"},
+		{"leading_empty", "{id}_{time}.{ext}", Fields{Ext: ".gif", Time: at}, "130405.gif"},
+		{"unix", "cat-{unix}", Fields{Time: at}, "cat-1714568645"},
+		{"unsafe_values", "{id}.{ext}", Fields{ID: "../../etc/passwd", Ext: "png"}, "etc_passwd.png"},
+		{"all_empty", "{id}", Fields{}, "cat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.tmpl, tt.fields)
+			testutil.AssertNoError(t, err, "Render should succeed")
+			testutil.AssertEqual(t, tt.want, got, "name")
+		})
+	}
+}
+
+// TestRender_Errors tests malformed templates are rejected
+func TestRender_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		want error
+	}{
+		{"unknown", "{breed}.{ext}", ErrUnknownField},
+		{"unclosed", "{id", ErrUnclosedField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Render(tt.tmpl, Fields{})
+			testutil.AssertTrue(t, errors.Is(err, tt.want), "should be "+tt.want.Error())
+		})
+	}
+}
+
+// TestSanitize tests names are made safe
+func TestSanitize(t *testing.T) {
+	long := strings.Repeat("a", 300) + ".png"
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "cat.png", "cat.png"},
+		{"separators", `a/b\c:d.png`, "a_b_c_d.png"},
+		{"control", "a\x00b\n.png", "a_b_.png"},
+		{"dots_and_spaces", " ..cat.png. ", "cat.png"},
+		{"reserved", "con.png", "_con.png"},
+		{"reserved_no_ext", "LPT1", "_LPT1"},
+		{"empty", "", "cat"},
+		{"only_dots", "...", "cat"},
+		{"long", long, strings.Repeat("a", maxNameBytes-4) + ".png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, Sanitize(tt.in), "sanitized")
+		})
+	}
+}
+
+// TestSanitize_MultiByte tests long names aren't cut inside a character
+func TestSanitize_MultiByte(t *testing.T) {
+	got := Sanitize(strings.Repeat("ñ", 150))
+	testutil.AssertTrue(t, len(got) <= maxNameBytes, "within limit")
+	testutil.AssertEqual(t, strings.Repeat("ñ", maxNameBytes/2), got, "whole characters")
+}
+
+// TestCreate tests taken names get a numbered suffix
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	for _, want := range []string{"cat.png", "cat-1.png", "cat-2.png"} {
+		f, err := Create(dir, "cat.png")
+		testutil.AssertNoError(t, err, "Create should succeed")
+		testutil.AssertEqual(t, filepath.Join(dir, want), f.Name(), "file name")
+		f.Close()
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "cat.png"))
+	testutil.AssertNoError(t, err, "first file kept")
+}
+
+// TestCreate_MissingDir tests other errors aren't retried
+func TestCreate_MissingDir(t *testing.T) {
+	_, err := Create(filepath.Join(t.TempDir(), "missing"), "cat.png")
+	testutil.AssertTrue(t, errors.Is(err, os.ErrNotExist), "should be not exist")
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"errors"
+	"image"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gioui.org/io/semantic"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+const imageAreaLabel = "Cat picture"
+
+// announcer holds the latest status message for assistive technology. Fetch
+// goroutines update it and the image area exposes it through the semantic
+// tree, so a screen reader hears more than a silent image swap.
+type announcer struct {
+	mu   sync.Mutex
+	text string
+}
+
+func (a *announcer) set(text string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.text = text
+}
+
+func (a *announcer) get() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.text
+}
+
+// loadedMessage describes a newly displayed cat
+func loadedMessage(meta *api.CatMetadata) string {
+	if meta == nil || len(meta.GetTags()) == 0 {
+		return "New cat loaded"
+	}
+	return "New cat loaded, tags: " + strings.Join(meta.GetTags(), ", ")
+}
+
+// failedMessage describes a failed fetch without the raw Go error
+func failedMessage(err error) string {
+	var timeout interface{ Timeout() bool }
+	var statusErr *api.StatusError
+	switch {
+	case errors.Is(err, api.ErrTimeout), errors.As(err, &timeout) && timeout.Timeout():
+		return "Fetch failed: timed out"
+	case errors.As(err, &statusErr):
+		return statusMessage(statusErr.StatusCode)
+	case errors.Is(err, api.ErrImageTooLarge):
+		return "Fetch failed: image too large"
+	case errors.Is(err, api.ErrDecode), errors.Is(err, image.ErrFormat):
+		return "Fetch failed: image could not be decoded"
+	case errors.Is(err, api.ErrMetadata):
+		return "Fetch failed: the cat server sent a bad response"
+	case errors.Is(err, api.ErrNoCat):
+		return "Fetch failed: no cat matched"
+	case errors.Is(err, api.ErrNetwork):
+		return "Fetch failed: network error"
+	default:
+		return "Fetch failed"
+	}
+}
+
+// statusMessage explains an HTTP status from the cat server
+func statusMessage(code int) string {
+	switch {
+	case code == http.StatusNotFound:
+		return "Fetch failed: no cat found"
+	case code == http.StatusTooManyRequests:
+		return "Fetch failed: too many requests, try again shortly"
+	case code >= 500:
+		return "Fetch failed: the cat server is down"
+	default:
+		return "Fetch failed: the cat server refused the request"
+	}
+}
+
+// layoutAnnounced lays out w and labels its area for assistive technology
+func layoutAnnounced(gtx layout.Context, label, description string, w layout.Widget) layout.Dimensions {
+	// the area is only known after layout, record the widget and replay it inside the semantic area
+	macro := op.Record(gtx.Ops)
+	dims := w(gtx)
+	call := macro.Stop()
+
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	semantic.LabelOp(label).Add(gtx.Ops)
+	if description != "" {
+		semantic.DescriptionOp(description).Add(gtx.Ops)
+	}
+	call.Add(gtx.Ops)
+
+	return dims
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+// TestAnnouncer tests storing the latest status message
+func TestAnnouncer(t *testing.T) {
+	var a announcer
+	testutil.AssertEqual(t, "", a.get(), "initially empty")
+
+	a.set("Fetching a cat")
+	testutil.AssertEqual(t, "Fetching a cat", a.get(), "after set")
+}
+
+// TestLoadedMessage tests the announcement for a new cat
+func TestLoadedMessage(t *testing.T) {
+	testutil.AssertEqual(t, "New cat loaded", loadedMessage(nil), "nil metadata")
+	testutil.AssertEqual(t, "New cat loaded", loadedMessage(&api.CatMetadata{}), "no tags")
+	testutil.AssertEqual(t, "New cat loaded, tags: sleepy, orange",
+		loadedMessage(&api.CatMetadata{Tags: []string{"sleepy", "orange"}}), "with tags")
+}
+
+// TestFailedMessage tests the announcement for a failed fetch
+func TestFailedMessage(t *testing.T) {
+	timeoutErr := &url.Error{Op: "Get", URL: "https://cataas.com/cat", Err: context.DeadlineExceeded}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "timeout", err: fmt.Errorf("request abc: %w", timeoutErr), want: "Fetch failed: timed out"},
+		{name: "api_timeout", err: fmt.Errorf("%w: slow", api.ErrTimeout), want: "Fetch failed: timed out"},
+		{name: "too_large", err: fmt.Errorf("wrapped: %w", api.ErrImageTooLarge), want: "Fetch failed: image too large"},
+		{name: "unknown_format", err: image.ErrFormat, want: "Fetch failed: image could not be decoded"},
+		{name: "decode", err: fmt.Errorf("%w: %w", api.ErrDecode, errors.New("bad huffman code")), want: "Fetch failed: image could not be decoded"},
+		{name: "metadata", err: api.ErrNoImageURL, want: "Fetch failed: the cat server sent a bad response"},
+		{name: "no_cat", err: api.ErrNoCat, want: "Fetch failed: no cat matched"},
+		{name: "not_found", err: &api.StatusError{StatusCode: http.StatusNotFound}, want: "Fetch failed: no cat found"},
+		{name: "rate_limited", err: &api.StatusError{StatusCode: http.StatusTooManyRequests}, want: "Fetch failed: too many requests, try again shortly"},
+		{name: "server_down", err: &api.RequestError{RequestID: "abc", Err: &api.StatusError{StatusCode: http.StatusBadGateway}}, want: "Fetch failed: the cat server is down"},
+		{name: "forbidden", err: &api.StatusError{StatusCode: http.StatusForbidden}, want: "Fetch failed: the cat server refused the request"},
+		{name: "network", err: fmt.Errorf("%w: %w", api.ErrNetwork, errors.New("connection refused")), want: "Fetch failed: network error"},
+		{name: "other", err: errors.New("something else"), want: "Fetch failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, failedMessage(tt.err), "message")
+		})
+	}
+}
+
+// TestLayoutAnnounced tests that the wrapped widget's dimensions are kept
+func TestLayoutAnnounced(t *testing.T) {
+	var ops op.Ops
+	gtx := layout.Context{
+		Ops: &ops,
+		Constraints: layout.Constraints{
+			Max: image.Pt(200, 100),
+		},
+	}
+
+	dims := layoutAnnounced(gtx, imageAreaLabel, "New cat loaded", func(gtx layout.Context) layout.Dimensions {
+		return layout.Dimensions{Size: image.Pt(120, 80)}
+	})
+	testutil.AssertEqual(t, image.Pt(120, 80), dims.Size, "dimensions")
+}
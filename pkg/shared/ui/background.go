@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"sync"
+
+	"github.com/bmj2728/catfetch/pkg/shared/catpic"
+)
+
+var (
+	backgroundMu sync.Mutex
+	background   catpic.Background
+)
+
+// SetBackground sets what fills the image area around the cat, nothing by
+// default. It takes effect when Run starts.
+func SetBackground(bg catpic.Background) {
+	backgroundMu.Lock()
+	defer backgroundMu.Unlock()
+	background = bg
+}
+
+func currentBackground() catpic.Background {
+	backgroundMu.Lock()
+	defer backgroundMu.Unlock()
+	return background
+}
@@ -0,0 +1,17 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/catpic"
+)
+
+// TestSetBackground tests the image area background setting
+func TestSetBackground(t *testing.T) {
+	testutil.AssertEqual(t, catpic.BackgroundNone, currentBackground().Kind, "no background by default")
+
+	SetBackground(catpic.BlurredBackground())
+	defer SetBackground(catpic.Background{})
+	testutil.AssertEqual(t, catpic.BlurredBackground(), currentBackground(), "background set")
+}
@@ -0,0 +1,23 @@
+package ui
+
+import "sync"
+
+var (
+	convertMu    sync.Mutex
+	convertNRGBA bool
+)
+
+// SetConvertNRGBA makes cats get converted to NRGBA once when they arrive
+// rather than on every frame, see catpic.CatPic.SetConvertNRGBA. It's off
+// by default and takes effect when Run starts.
+func SetConvertNRGBA(enabled bool) {
+	convertMu.Lock()
+	defer convertMu.Unlock()
+	convertNRGBA = enabled
+}
+
+func currentConvertNRGBA() bool {
+	convertMu.Lock()
+	defer convertMu.Unlock()
+	return convertNRGBA
+}
@@ -0,0 +1,16 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestSetConvertNRGBA tests the conversion setting is off unless asked for
+func TestSetConvertNRGBA(t *testing.T) {
+	testutil.AssertTrue(t, !currentConvertNRGBA(), "off by default")
+
+	SetConvertNRGBA(true)
+	defer SetConvertNRGBA(false)
+	testutil.AssertTrue(t, currentConvertNRGBA(), "on once set")
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"image/color"
+	"sync"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+var errorText = color.NRGBA{R: 255, G: 85, B: 85, A: 255}
+
+// errorBar shows why the last fetch or save failed, with a retry button for
+// fetches, for people who started catfetch from a launcher and never see
+// the log. Fetch and save goroutines set the message and the event loop
+// draws it.
+type errorBar struct {
+	mu        sync.Mutex
+	text      string
+	retryable bool
+	retry     widget.Clickable
+}
+
+// show displays why a fetch failed, offering to retry it
+func (b *errorBar) show(text string) {
+	b.set(text, true)
+}
+
+// note displays a failure there is nothing to retry for, such as a save
+func (b *errorBar) note(text string) {
+	b.set(text, false)
+}
+
+func (b *errorBar) set(text string, retryable bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.text = text
+	b.retryable = retryable
+}
+
+func (b *errorBar) clear() {
+	b.set("", false)
+}
+
+func (b *errorBar) message() (text string, retryable bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.text, b.retryable
+}
+
+// layout draws the message and retry button, or nothing when the last
+// fetch succeeded
+func (b *errorBar) layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	text, retryable := b.message()
+	if text == "" {
+		return layout.Dimensions{}
+	}
+
+	return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					lbl := material.Body1(th, text)
+					lbl.Color = errorText
+					return lbl.Layout(gtx)
+				})
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if !retryable {
+					return layout.Dimensions{}
+				}
+				return layoutButton(gtx, th, &b.retry, "Retry", 4)
+			}),
+		)
+	})
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+// TestErrorBar tests the message is kept until the next fetch clears it
+func TestErrorBar(t *testing.T) {
+	var b errorBar
+	text, _ := b.message()
+	testutil.AssertEqual(t, "", text, "no error at first")
+
+	b.show(failedMessage(api.ErrNetwork))
+	text, retryable := b.message()
+	testutil.AssertEqual(t, "Fetch failed: network error", text, "error shown")
+	testutil.AssertTrue(t, retryable, "fetches can be retried")
+
+	b.note("Save failed")
+	text, retryable = b.message()
+	testutil.AssertEqual(t, "Save failed", text, "note shown")
+	testutil.AssertFalse(t, retryable, "nothing to retry")
+
+	b.clear()
+	text, _ = b.message()
+	testutil.AssertEqual(t, "", text, "cleared")
+}
+
+// TestErrorBar_LayoutEmpty tests nothing is drawn without an error
+func TestErrorBar_LayoutEmpty(t *testing.T) {
+	var b errorBar
+	var ops op.Ops
+	gtx := layout.Context{
+		Ops: &ops,
+		Constraints: layout.Constraints{
+			Max: image.Pt(300, 400),
+		},
+	}
+
+	dims := b.layout(gtx, nil)
+	testutil.AssertEqual(t, image.Point{}, dims.Size, "no space taken")
+}
@@ -1,22 +1,211 @@
 package ui
 
 import (
+	"context"
 	"image"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
-	"log"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/bmj2728/catfetch/pkg/shared/api"
 )
 
-func HandleButtonClick() (image.Image, *api.CatMetadata, error) {
-	img, metadata, err := api.RequestRandomCat(30 * time.Second)
+// fetchTimeouts apply to button fetches unless an option overrides them. A
+// stalled connection or download fails well before the total.
+var fetchTimeouts = api.Timeouts{
+	Dial:           5 * time.Second,
+	TLSHandshake:   5 * time.Second,
+	ResponseHeader: 10 * time.Second,
+	Body:           15 * time.Second,
+	Total:          30 * time.Second,
+}
+
+var (
+	providerMu sync.RWMutex
+	provider   = api.DefaultProvider()
+)
+
+// SetProvider changes where the UI gets its cats, cataas by default. Cats
+// prefetched from the previous provider are dropped.
+func SetProvider(p api.CatProvider) {
+	providerMu.Lock()
+	provider = p
+	providerMu.Unlock()
+	StopPrefetch()
+}
+
+func currentProvider() api.CatProvider {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	return provider
+}
+
+// HandleButtonClick fetches a random cat from the current provider, passing
+// options such as api.WithTags through
+func HandleButtonClick(opts ...api.Option) (image.Image, *api.CatMetadata, error) {
+	opts = append([]api.Option{api.WithTimeouts(fetchTimeouts)}, opts...)
+	res, err := currentProvider().FetchRandom(context.Background(), opts...)
 	if err != nil {
-		log.Printf("Error fetching image: %v", err)
+		slog.Warn("Error fetching image", "err", err)
 		return nil, nil, err
 	}
 
-	return img, metadata, nil
+	return res.Image, res.Metadata, nil
+}
+
+// blurredCatRadius is the server-side blur used by the blurred cat toggle
+const blurredCatRadius = 8
+
+// fetchOptions maps the filter toggles to API options
+func fetchOptions(mono, blurred bool) []api.Option {
+	var opts []api.Option
+	if mono {
+		opts = append(opts, api.WithFilter(api.CAASImageFilterMono))
+	}
+	if blurred {
+		opts = append(opts, api.WithBlur(blurredCatRadius))
+	}
+	return opts
+}
+
+// prefetchCount is how many cats are kept ready for the fetch button
+const prefetchCount = 2
+
+// fetchSettings are the toggles a fetch depends on, prefetched cats are only
+// good for the settings they were fetched with
+type fetchSettings struct {
+	mono    bool
+	blurred bool
+	tag     string // only cats with this tag, any cat if empty
+}
+
+func (s fetchSettings) options() []api.Option {
+	opts := append([]api.Option{api.WithTimeouts(fetchTimeouts)}, fetchOptions(s.mono, s.blurred)...)
+	if s.tag != "" {
+		opts = append(opts, api.WithTags(s.tag))
+	}
+	return opts
+}
+
+var (
+	prefetchMu       sync.Mutex
+	prefetcher       *api.Prefetcher
+	prefetchSettings fetchSettings
+
+	// a second prefetcher for settings about to be used, such as a tag
+	// still being typed
+	candidate         *api.Prefetcher
+	candidateSettings fetchSettings
+
+	// set by StopPrefetch until warmPrefetch, so fetches meanwhile don't
+	// start a prefetcher while idle or saving power
+	prefetchPaused bool
+)
+
+// prefetcherFor returns the prefetcher for s, replacing one started for
+// other settings. A candidate started for s takes over with the cats it
+// already has.
+func prefetcherFor(s fetchSettings) *api.Prefetcher {
+	prefetchMu.Lock()
+	defer prefetchMu.Unlock()
+	if prefetcher != nil && prefetchSettings == s {
+		return prefetcher
+	}
+	if prefetcher != nil {
+		// don't hold up the frame while the old one winds down
+		go prefetcher.Close()
+	}
+	if candidate != nil && candidateSettings == s {
+		prefetcher, candidate = candidate, nil
+	} else {
+		prefetcher = api.NewPrefetcher(currentProvider(), prefetchCount, s.options()...)
+	}
+	prefetchSettings = s
+	return prefetcher
+}
+
+// activePrefetcher returns the prefetcher for s, or nil while prefetching
+// is stopped
+func activePrefetcher(s fetchSettings) *api.Prefetcher {
+	prefetchMu.Lock()
+	paused := prefetchPaused
+	prefetchMu.Unlock()
+	if paused {
+		return nil
+	}
+	return prefetcherFor(s)
+}
+
+// warmCandidate starts keeping cats ready for s besides the current
+// settings, so switching to s is instant. A candidate for other settings is
+// cancelled. It is cheap to call again with the same settings.
+func warmCandidate(s fetchSettings) {
+	prefetchMu.Lock()
+	defer prefetchMu.Unlock()
+	if prefetcher != nil && prefetchSettings == s {
+		return
+	}
+	if candidate != nil && candidateSettings == s {
+		return
+	}
+	if candidate != nil {
+		go candidate.Close()
+	}
+	candidate = api.NewPrefetcher(currentProvider(), prefetchCount, s.options()...)
+	candidateSettings = s
+}
+
+// dropCandidate cancels the candidate prefetcher, if any
+func dropCandidate() {
+	prefetchMu.Lock()
+	defer prefetchMu.Unlock()
+	if candidate != nil {
+		go candidate.Close()
+		candidate = nil
+	}
+}
+
+// warmPrefetch starts keeping cats ready for s, it is cheap to call again
+// with the same settings
+func warmPrefetch(s fetchSettings) {
+	prefetchMu.Lock()
+	prefetchPaused = false
+	prefetchMu.Unlock()
+	prefetcherFor(s)
+}
+
+// StopPrefetch stops fetching cats in the background until prefetching is
+// warmed up again, fetches meanwhile go straight to the provider
+func StopPrefetch() {
+	prefetchMu.Lock()
+	defer prefetchMu.Unlock()
+	prefetchPaused = true
+	if prefetcher != nil {
+		prefetcher.Close()
+		prefetcher = nil
+	}
+	if candidate != nil {
+		candidate.Close()
+		candidate = nil
+	}
+}
+
+// fetchCat returns a prefetched cat for s when one is ready, fetching one
+// now otherwise. While prefetching is stopped it only fetches the one cat.
+func fetchCat(s fetchSettings) (*api.FetchResult, error) {
+	var res *api.FetchResult
+	var err error
+	if p := activePrefetcher(s); p != nil {
+		res, err = p.Next(context.Background())
+	} else {
+		res, err = currentProvider().FetchRandom(context.Background(), s.options()...)
+	}
+	if err != nil {
+		slog.Warn("Error fetching image", "err", err)
+		return nil, err
+	}
+	return res, nil
 }
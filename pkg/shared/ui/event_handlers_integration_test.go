@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
 )
 
 // TestHandleButtonClick_RealFunction_Success tests the actual HandleButtonClick function
@@ -64,7 +66,7 @@ func TestHandleButtonClick_RealFunction_Success(t *testing.T) {
 			defer func() { http.DefaultTransport = oldTransport }()
 
 			// ACTUALLY CALL HandleButtonClick!
-			img, meta, err := HandleButtonClick()
+			img, meta, err := HandleButtonClick(allowImageServer(imageServer.URL))
 
 			// Verify success
 			testutil.AssertNoError(t, err, "HandleButtonClick should succeed")
@@ -177,7 +179,7 @@ func TestHandleButtonClick_RealFunction_ImageFetchError(t *testing.T) {
 	defer func() { http.DefaultTransport = oldTransport }()
 
 	// Call the function
-	img, meta, err := HandleButtonClick()
+	img, meta, err := HandleButtonClick(allowImageServer(imageServer.URL))
 
 	// Should fail when trying to decode image
 	testutil.AssertError(t, err, "should fail with bad image")
@@ -245,6 +247,13 @@ func TestHandleButtonClick_APIIntegration(t *testing.T) {
 	})
 }
 
+// allowImageServer lets metadata served for cataas.com point at the test
+// server at serverURL for the image
+func allowImageServer(serverURL string) api.Option {
+	u, _ := url.Parse(serverURL)
+	return api.WithAllowedHosts(u.Hostname())
+}
+
 // buttonClickRedirectTransport redirects HTTP requests to test servers
 type buttonClickRedirectTransport struct {
 	metadataURL   string
@@ -1,13 +1,16 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
 )
 
 // TestHandleButtonClick_Success tests successful button click handling
@@ -223,3 +226,160 @@ func TestHandleButtonClick_NilHandling(t *testing.T) {
 // func (h *EventHandler) HandleButtonClick() (image.Image, *api.CatMetadata, error)
 //
 // For now, these tests document the expected behavior and verify the function exists.
+
+// TestFetchOptions tests that the filter toggles reach the request
+func TestFetchOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		mono       bool
+		blurred    bool
+		wantFilter string
+		wantBlur   string
+	}{
+		{"none", false, false, "", ""},
+		{"mono", true, false, "mono", ""},
+		{"blurred", false, true, "", fmt.Sprint(blurredCatRadius)},
+		{"both", true, true, "mono", fmt.Sprint(blurredCatRadius)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filter, blur string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/image" {
+					w.Header().Set("Content-Type", "image/png")
+					w.Write(testutil.ValidPNGBytes())
+					return
+				}
+				filter = r.URL.Query().Get("filter")
+				blur = r.URL.Query().Get("blur")
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(testutil.ValidMetadataJSONWithURL("/image")))
+			}))
+			defer server.Close()
+
+			opts := append(fetchOptions(tt.mono, tt.blurred), api.WithBaseURL(server.URL))
+			_, _, err := HandleButtonClick(opts...)
+			testutil.AssertNoError(t, err, "HandleButtonClick should succeed")
+			testutil.AssertEqual(t, tt.wantFilter, filter, "filter param")
+			testutil.AssertEqual(t, tt.wantBlur, blur, "blur param")
+		})
+	}
+}
+
+// fakeProvider records calls and returns a fixed cat, it is safe to use
+// from the prefetcher
+type fakeProvider struct {
+	calls atomic.Int32
+	opts  atomic.Int32
+}
+
+func (f *fakeProvider) FetchRandom(ctx context.Context, opts ...api.Option) (*api.FetchResult, error) {
+	f.calls.Add(1)
+	f.opts.Store(int32(len(opts)))
+	return &api.FetchResult{
+		Image:    testutil.CreateColorImage(4, 4, 255, 0, 0),
+		Metadata: &api.CatMetadata{ID: "fake"},
+	}, nil
+}
+
+func (f *fakeProvider) FetchByID(ctx context.Context, id string, opts ...api.Option) (*api.FetchResult, error) {
+	return f.FetchRandom(ctx, opts...)
+}
+
+func (f *fakeProvider) ListTags(ctx context.Context, opts ...api.Option) (api.CAASTags, error) {
+	return api.CAASTags{"fake"}, nil
+}
+
+// TestHandleButtonClick_Provider tests that fetches go through the current provider
+func TestHandleButtonClick_Provider(t *testing.T) {
+	fake := &fakeProvider{}
+	SetProvider(fake)
+	defer SetProvider(api.DefaultProvider())
+
+	img, meta, err := HandleButtonClick(api.WithTags("cute"))
+	testutil.AssertNoError(t, err, "HandleButtonClick should succeed")
+	testutil.AssertNotNil(t, img, "image")
+	testutil.AssertEqual(t, "fake", meta.GetID(), "metadata from the provider")
+	testutil.AssertEqual(t, int32(1), fake.calls.Load(), "provider calls")
+	testutil.AssertEqual(t, int32(2), fake.opts.Load(), "timeout plus caller options")
+}
+
+// TestFetchCat_Prefetch tests fetching through the prefetcher for the toggles
+func TestFetchCat_Prefetch(t *testing.T) {
+	fake := &fakeProvider{}
+	SetProvider(fake)
+	defer SetProvider(api.DefaultProvider())
+
+	res, err := fetchCat(fetchSettings{})
+	testutil.AssertNoError(t, err, "fetchCat should succeed")
+	testutil.AssertNotNil(t, res.Image, "image")
+	testutil.AssertEqual(t, "fake", res.Metadata.GetID(), "metadata from the provider")
+
+	plain := prefetcherFor(fetchSettings{})
+	testutil.AssertTrue(t, plain == prefetcherFor(fetchSettings{}), "same settings reuse the prefetcher")
+	mono := prefetcherFor(fetchSettings{mono: true})
+	testutil.AssertTrue(t, plain != mono, "new settings replace the prefetcher")
+
+	StopPrefetch()
+	testutil.AssertTrue(t, mono != prefetcherFor(fetchSettings{mono: true}), "stopping drops the prefetcher")
+}
+
+// TestFetchCat_Paused tests a fetch while prefetching is stopped, when idle
+// or saving power, fetches only the one cat and starts no prefetcher
+func TestFetchCat_Paused(t *testing.T) {
+	fake := &fakeProvider{}
+	SetProvider(fake)
+	defer SetProvider(api.DefaultProvider())
+	defer StopPrefetch()
+
+	StopPrefetch()
+	res, err := fetchCat(fetchSettings{})
+	testutil.AssertNoError(t, err, "fetchCat should succeed")
+	testutil.AssertEqual(t, "fake", res.Metadata.GetID(), "metadata from the provider")
+	prefetchMu.Lock()
+	testutil.AssertNil(t, prefetcher, "no prefetcher started while stopped")
+	prefetchMu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	testutil.AssertEqual(t, int32(1), fake.calls.Load(), "only the cat asked for")
+
+	warmPrefetch(fetchSettings{})
+	_, err = fetchCat(fetchSettings{})
+	testutil.AssertNoError(t, err, "fetchCat should succeed")
+	prefetchMu.Lock()
+	testutil.AssertNotNil(t, prefetcher, "prefetching again once warmed up")
+	prefetchMu.Unlock()
+}
+
+// TestWarmCandidate tests a candidate prefetcher takes over once its
+// settings are used, and is cancelled when they change
+func TestWarmCandidate(t *testing.T) {
+	fake := &fakeProvider{}
+	SetProvider(fake)
+	defer SetProvider(api.DefaultProvider())
+	defer StopPrefetch()
+
+	plain := prefetcherFor(fetchSettings{})
+	warmCandidate(fetchSettings{})
+	testutil.AssertTrue(t, plain == prefetcherFor(fetchSettings{}), "current settings need no candidate")
+
+	warmCandidate(fetchSettings{tag: "cute"})
+	prefetchMu.Lock()
+	cute := candidate
+	prefetchMu.Unlock()
+	warmCandidate(fetchSettings{tag: "cute"})
+	prefetchMu.Lock()
+	testutil.AssertTrue(t, cute == candidate, "same settings keep the candidate")
+	prefetchMu.Unlock()
+
+	testutil.AssertTrue(t, cute == prefetcherFor(fetchSettings{tag: "cute"}), "candidate takes over")
+	prefetchMu.Lock()
+	testutil.AssertNil(t, candidate, "candidate used up")
+	prefetchMu.Unlock()
+
+	warmCandidate(fetchSettings{tag: "orange"})
+	dropCandidate()
+	prefetchMu.Lock()
+	testutil.AssertNil(t, candidate, "candidate dropped")
+	prefetchMu.Unlock()
+}
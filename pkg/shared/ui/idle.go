@@ -0,0 +1,46 @@
+package ui
+
+import "time"
+
+// idleAfter is how long the window may go without input before background
+// fetching pauses
+const idleAfter = 10 * time.Minute
+
+// idleDetector tells when nobody is using the window: it lost focus, which
+// also happens when the screen locks, or there was no input for idleAfter.
+// It is only used from the event loop.
+type idleDetector struct {
+	last      time.Time
+	unfocused bool
+	now       func() time.Time
+}
+
+func newIdleDetector() *idleDetector {
+	d := &idleDetector{now: time.Now}
+	d.last = d.now()
+	return d
+}
+
+// touch records input
+func (d *idleDetector) touch() {
+	d.last = d.now()
+}
+
+// setFocused records the window gaining or losing focus, gaining it counts
+// as input
+func (d *idleDetector) setFocused(focused bool) {
+	d.unfocused = !focused
+	if focused {
+		d.touch()
+	}
+}
+
+// idle reports whether background work should pause
+func (d *idleDetector) idle() bool {
+	return d.unfocused || d.remaining() == 0
+}
+
+// remaining is how long until the window counts as idle without input
+func (d *idleDetector) remaining() time.Duration {
+	return max(idleAfter-d.now().Sub(d.last), 0)
+}
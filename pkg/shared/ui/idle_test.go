@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestIdleDetector tests input, time and focus changes
+func TestIdleDetector(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	d := newIdleDetector()
+	d.now = func() time.Time { return now }
+	d.touch()
+
+	testutil.AssertTrue(t, !d.idle(), "active after input")
+	testutil.AssertEqual(t, idleAfter, d.remaining(), "remaining after input")
+
+	now = now.Add(idleAfter - time.Second)
+	testutil.AssertTrue(t, !d.idle(), "active just before the limit")
+	testutil.AssertEqual(t, time.Second, d.remaining(), "remaining before the limit")
+
+	now = now.Add(time.Second)
+	testutil.AssertTrue(t, d.idle(), "idle at the limit")
+	testutil.AssertEqual(t, time.Duration(0), d.remaining(), "nothing remaining")
+
+	d.touch()
+	testutil.AssertTrue(t, !d.idle(), "input resumes")
+
+	d.setFocused(false)
+	testutil.AssertTrue(t, d.idle(), "idle when unfocused")
+
+	now = now.Add(time.Hour)
+	d.setFocused(true)
+	testutil.AssertTrue(t, !d.idle(), "focus counts as input")
+}
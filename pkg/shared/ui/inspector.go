@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+
+	"gioui.org/app"
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/bmj2728/catfetch/pkg/shared/imgutil"
+)
+
+var (
+	inspectorText = color.NRGBA{R: 248, G: 248, B: 242, A: 255}
+	histogramBg   = color.NRGBA{R: 68, G: 71, B: 90, A: 255}
+	histogramRed  = color.NRGBA{R: 255, G: 85, B: 85, A: 110}
+	histogramGrn  = color.NRGBA{R: 80, G: 250, B: 123, A: 110}
+	histogramBlu  = color.NRGBA{R: 98, G: 114, B: 164, A: 140}
+	histogramLuma = color.NRGBA{R: 248, G: 248, B: 242, A: 90}
+)
+
+const histogramHeight = unit.Dp(72)
+
+// imageInfo is everything the inspector shows about one image
+type imageInfo struct {
+	width    int
+	height   int
+	bitDepth int
+	hasAlpha bool
+	size     int // bytes downloaded, 0 if unknown
+	hist     imgutil.Histogram
+}
+
+func computeImageInfo(img image.Image, size int) *imageInfo {
+	b := img.Bounds()
+	return &imageInfo{
+		width:    b.Dx(),
+		height:   b.Dy(),
+		bitDepth: imgutil.BitDepth(img),
+		hasAlpha: imgutil.HasAlpha(img),
+		size:     size,
+		hist:     imgutil.ComputeHistogram(img),
+	}
+}
+
+func (i *imageInfo) summary() string {
+	s := fmt.Sprintf("%d×%d · %d-bit", i.width, i.height, i.bitDepth)
+	if i.hasAlpha {
+		s += " · alpha"
+	}
+	if i.size > 0 {
+		s += " · " + formatBytes(i.size)
+	}
+	return s
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+// inspector is a toggleable panel with a histogram and basic info about the
+// current image. The info is computed in a goroutine the first time the panel
+// is shown for an image.
+type inspector struct {
+	toggle  widget.Clickable
+	visible bool
+
+	mu   sync.Mutex
+	src  image.Image // the image info was, or is being, computed for
+	info *imageInfo
+}
+
+// update starts computing info for img when the panel is open and img is new
+func (in *inspector) update(w *app.Window, img image.Image, size int) {
+	if !in.visible || img == nil {
+		return
+	}
+
+	in.mu.Lock()
+	if in.src == img {
+		in.mu.Unlock()
+		return
+	}
+	in.src = img
+	in.info = nil
+	in.mu.Unlock()
+
+	go func() {
+		info := computeImageInfo(img, size)
+
+		in.mu.Lock()
+		// a newer image may have arrived while we were busy
+		if in.src == img {
+			in.info = info
+		}
+		in.mu.Unlock()
+		w.Invalidate()
+	}()
+}
+
+func (in *inspector) getInfo() *imageInfo {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.info
+}
+
+func (in *inspector) layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	info := in.getInfo()
+
+	return layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		if info == nil {
+			lbl := material.Body2(th, "Inspecting…")
+			lbl.Color = inspectorText
+			return lbl.Layout(gtx)
+		}
+
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layoutHistogram(gtx, &info.hist)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				lbl := material.Body2(th, info.summary())
+				lbl.Color = inspectorText
+				return lbl.Layout(gtx)
+			}),
+		)
+	})
+}
+
+// layoutHistogram plots each channel as a translucent filled curve
+func layoutHistogram(gtx layout.Context, hist *imgutil.Histogram) layout.Dimensions {
+	size := image.Pt(gtx.Constraints.Max.X, gtx.Dp(histogramHeight))
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	paint.ColorOp{Color: histogramBg}.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+
+	peak := hist.Peak()
+	if peak > 0 {
+		fillHistogramChannel(gtx.Ops, &hist.Luma, peak, size, histogramLuma)
+		fillHistogramChannel(gtx.Ops, &hist.Red, peak, size, histogramRed)
+		fillHistogramChannel(gtx.Ops, &hist.Green, peak, size, histogramGrn)
+		fillHistogramChannel(gtx.Ops, &hist.Blue, peak, size, histogramBlu)
+	}
+
+	return layout.Dimensions{Size: size}
+}
+
+func fillHistogramChannel(ops *op.Ops, bins *[256]uint32, peak uint32, size image.Point, col color.NRGBA) {
+	w, h := float32(size.X), float32(size.Y)
+
+	var p clip.Path
+	p.Begin(ops)
+	p.MoveTo(f32.Pt(0, h))
+	for i, v := range bins {
+		x := float32(i) * w / float32(len(bins)-1)
+		y := h - float32(v)*h/float32(peak)
+		p.LineTo(f32.Pt(x, y))
+	}
+	p.LineTo(f32.Pt(w, h))
+	p.Close()
+
+	paint.FillShape(ops, col, clip.Outline{Path: p.End()}.Op())
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestFormatBytes tests human readable byte sizes
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1024, want: "1.0 KiB"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 5 * 1024 * 1024, want: "5.0 MiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, formatBytes(tt.n), "formatted size")
+		})
+	}
+}
+
+// TestComputeImageInfo tests the info shown in the inspector
+func TestComputeImageInfo(t *testing.T) {
+	img := testutil.CreateColorImage(30, 20, 255, 0, 0)
+	info := computeImageInfo(img, 2048)
+
+	testutil.AssertEqual(t, 30, info.width, "width")
+	testutil.AssertEqual(t, 20, info.height, "height")
+	testutil.AssertEqual(t, 8, info.bitDepth, "bit depth")
+	testutil.AssertFalse(t, info.hasAlpha, "opaque image")
+	testutil.AssertEqual(t, uint32(600), info.hist.Red[255], "histogram")
+	testutil.AssertEqual(t, "30×20 · 8-bit · 2.0 KiB", info.summary(), "summary")
+}
+
+// TestLayoutHistogram tests the histogram plot dimensions
+func TestLayoutHistogram(t *testing.T) {
+	info := computeImageInfo(testutil.CreateGradientImage(64, 8), 0)
+
+	var ops op.Ops
+	gtx := layout.Context{
+		Ops: &ops,
+		Constraints: layout.Constraints{
+			Max: image.Pt(300, 400),
+		},
+	}
+
+	dims := layoutHistogram(gtx, &info.hist)
+	testutil.AssertEqual(t, 300, dims.Size.X, "histogram uses full width")
+	testutil.AssertEqual(t, gtx.Dp(histogramHeight), dims.Size.Y, "histogram height")
+}
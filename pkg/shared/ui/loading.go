@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+// loaderSize is the diameter of the spinner shown while a cat loads
+const loaderSize = unit.Dp(48)
+
+// layoutLoading draws content, with a spinner centered over the whole area
+// while loading. The content keeps its place so the previous cat stays put
+// until the new one arrives.
+func layoutLoading(gtx layout.Context, th *material.Theme, loading bool, content layout.Widget) layout.Dimensions {
+	if !loading {
+		return content(gtx)
+	}
+	return layout.Stack{}.Layout(gtx,
+		layout.Stacked(content),
+		layout.Expanded(func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				size := gtx.Dp(loaderSize)
+				gtx.Constraints = layout.Exact(gtx.Constraints.Constrain(image.Pt(size, size)))
+				loader := material.Loader(th)
+				loader.Color = color.NRGBA{R: 189, G: 147, B: 249, A: 255}
+				return loader.Layout(gtx)
+			})
+		}),
+	)
+}
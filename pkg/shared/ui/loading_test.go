@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget/material"
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestLayoutLoading tests the spinner covers the area only while loading
+func TestLayoutLoading(t *testing.T) {
+	th := material.NewTheme()
+	content := func(gtx layout.Context) layout.Dimensions {
+		return layout.Dimensions{Size: image.Pt(100, 50)}
+	}
+
+	tests := []struct {
+		name    string
+		loading bool
+		want    image.Point
+	}{
+		{"idle", false, image.Pt(100, 50)},
+		{"loading", true, image.Pt(300, 400)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops op.Ops
+			gtx := layout.Context{
+				Ops: &ops,
+				Constraints: layout.Constraints{
+					Max: image.Pt(300, 400),
+				},
+			}
+			dims := layoutLoading(gtx, th, tt.loading, content)
+			testutil.AssertEqual(t, tt.want, dims.Size, "size")
+		})
+	}
+}
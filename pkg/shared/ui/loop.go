@@ -1,14 +1,17 @@
 package ui
 
 import (
+	"errors"
 	"image"
 	"image/color"
 	//"image"
-	"log"
+	"log/slog"
+	"time"
 
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"github.com/bmj2728/catfetch/pkg/shared/catpic"
+	"github.com/bmj2728/catfetch/pkg/shared/savedialog"
 
 	"gioui.org/app"
 	"gioui.org/layout"
@@ -19,10 +22,40 @@ import (
 )
 
 func Run(w *app.Window) error {
-	// button
-	var fetchButton widget.Clickable
+	// buttons
+	var fetchButton, saveButton widget.Clickable
+	// server-side filter toggles
+	var monoToggle, blurToggle widget.Bool
 	// thread-safe image wrapper
 	var currentImage catpic.CatPic //threadsafe wrapper for image.Image
+	// make transparent cats visible against the dark window
+	currentImage.SetAlphaCheckerboard(true)
+	// fill the letterbox around the cat as configured
+	currentImage.SetBackground(currentBackground())
+	// convert once when a cat arrives instead of on every frame, if asked
+	currentImage.SetConvertNRGBA(currentConvertNRGBA())
+	// metadata for the cat on screen
+	var current catState
+	// histogram and image info panel
+	var insp inspector
+	// status messages for screen readers
+	var status announcer
+	// why the last fetch failed, with a retry button
+	var fetchErr errorBar
+	// holds fetches back while the provider asked us to wait
+	var limit rateLimit
+	// mood buttons setting the tag and filters in one go
+	moods := newPresetBar(currentPresets())
+	// limits fetches to a tag, offering the provider's tags
+	search := newTagSearch()
+	go func() {
+		search.load()
+		w.Invalidate()
+	}()
+	// pauses prefetching while nobody is using the window
+	idle := newIdleDetector()
+	// pauses prefetching on battery and in power saver mode
+	battery := newPowerMonitor()
 	// Ops list
 	var ops op.Ops
 
@@ -34,8 +67,15 @@ func Run(w *app.Window) error {
 	for {
 		switch e := w.Event().(type) {
 		case app.DestroyEvent:
+			StopPrefetch()
 			return e.Err
 
+		case app.ConfigEvent:
+			idle.setFocused(e.Config.Focused)
+			if idle.idle() {
+				StopPrefetch()
+			}
+
 		case app.FrameEvent:
 			gtx := app.NewContext(&ops, e)
 
@@ -46,21 +86,131 @@ func Run(w *app.Window) error {
 			}
 			paint.FillShape(&ops, newBg, winRect.Op())
 
+			monoChanged := monoToggle.Update(gtx)
+			blurChanged := blurToggle.Update(gtx)
+			searched := search.update(gtx)
+			if monoChanged || blurChanged || searched {
+				idle.touch()
+			}
+
+			// a fetch applies the tag typed so far, retrying a failed fetch
+			// works the same
+			fetchClicked := fetchButton.Clicked(gtx)
+			retryClicked := fetchErr.retry.Clicked(gtx)
+			if fetchClicked {
+				search.apply()
+			}
+			// a mood sets the filters and fetches right away
+			if p, ok := moods.clicked(gtx); ok {
+				monoToggle.Value = p.Mono
+				blurToggle.Value = p.Blurred
+				search.set(p.Tag)
+				fetchClicked = true
+			}
+
+			// Keep cats for the current toggles ready while someone is
+			// around and power isn't short, and come back to check when
+			// they may have gone or the power state changed
+			settings := fetchSettings{mono: monoToggle.Value, blurred: blurToggle.Value, tag: search.tag}
+			if idle.idle() || battery.saving() {
+				StopPrefetch()
+			} else {
+				warmPrefetch(settings)
+				// get cats ready for a tag being typed, cancelling them
+				// once it changes again
+				if tag, ok := search.candidate(gtx.Now); ok {
+					next := settings
+					next.tag = tag
+					warmCandidate(next)
+				} else {
+					dropCandidate()
+				}
+			}
+			if left := search.settling(gtx.Now); left > 0 {
+				gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(left)})
+			}
+			if !idle.idle() {
+				gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(min(idle.remaining(), powerCheckEvery))})
+			}
+
+			// Hold fetches back while the provider asked us to wait,
+			// fetching once it's over if one was asked for meanwhile
+			wantFetch := fetchClicked || retryClicked
+			if ended, queued := limit.end(gtx.Now); ended {
+				fetchErr.clear()
+				wantFetch = wantFetch || queued
+			}
+			if left := limit.remaining(gtx.Now); left > 0 {
+				if wantFetch {
+					idle.touch()
+					limit.queue()
+					wantFetch = false
+				}
+				fetchErr.note(waitMessage(left, limit.queued()))
+				gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(nextTick(left))})
+			}
+
 			// Handle button click
-			if fetchButton.Clicked(gtx) && !currentImage.IsLoading() {
+			if wantFetch && !currentImage.IsLoading() {
+				idle.touch()
 				currentImage.SetLoading()
+				fetchErr.clear()
+				status.set("Fetching a cat")
 				go func(wind *app.Window) {
-					img, _, err := HandleButtonClick()
+					res, err := fetchCat(settings)
 					if err != nil {
-						log.Printf("Error handling button click: %v", err)
+						slog.Debug("Error handling button click", "err", err)
+						if wait, ok := rateLimitWait(err); ok {
+							limit.start(time.Now(), wait)
+							status.set(waitMessage(wait, false))
+						} else {
+							status.set(searchFailedMessage(err, settings.tag))
+							fetchErr.show(searchFailedMessage(err, settings.tag))
+						}
 					} else {
-						currentImage.SetImage(img)
+						current.setResult(res)
+						currentImage.SetImage(res.Image)
+						status.set(loadedMessage(res.Metadata))
 					}
 					currentImage.ClearLoading()
 					wind.Invalidate()
 				}(w)
 			}
 
+			// Save the cat on screen as downloaded where the save dialog
+			// says, and show where it went
+			if saveButton.Clicked(gtx) {
+				idle.touch()
+				data, format, meta := current.saveable()
+				fetchErr.clear()
+				go func() {
+					path, err := saveCurrent(data, format, meta)
+					if errors.Is(err, savedialog.ErrCancelled) {
+						status.set("Save cancelled")
+						w.Invalidate()
+						return
+					}
+					if err != nil {
+						slog.Warn("Error saving cat", "err", err)
+						status.set(saveFailedMessage(err))
+						fetchErr.note(saveFailedMessage(err))
+						w.Invalidate()
+						return
+					}
+					slog.Info("Saved cat", "path", path)
+					status.set("Saved to " + path)
+					w.Invalidate()
+					afterSaving(path)
+				}()
+			}
+
+			// Toggle the inspector, computing info for the current cat if needed
+			if insp.toggle.Clicked(gtx) {
+				idle.touch()
+				insp.visible = !insp.visible
+			}
+			insp.update(w, currentImage.GetImage(), current.imageSize())
+
 			// Layout UI components
 			layout.Flex{
 				Axis:    layout.Vertical,
@@ -68,11 +218,56 @@ func Run(w *app.Window) error {
 			}.Layout(gtx,
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 					return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-						return layoutButton(gtx, th, &fetchButton, 12)
+						// narrow insets so three buttons fit the default window
+						return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return layoutButton(gtx, th, &fetchButton, "Fetch a Cat", 6)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return layoutButton(gtx, th, &saveButton, "Save", 6)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return layoutButton(gtx, th, &insp.toggle, "Info", 6)
+							}),
+						)
+					})
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return layoutToggle(gtx, th, &monoToggle, "Mono cat")
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return layoutToggle(gtx, th, &blurToggle, "Blurred cat")
+							}),
+						)
 					})
 				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return moods.layout(gtx, th)
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return search.layout(gtx, th)
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return fetchErr.layout(gtx, th)
+				}),
 				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-					return layoutImageDisplay(gtx, &currentImage, 24)
+					return layoutAnnounced(gtx, imageAreaLabel, status.get(), func(gtx layout.Context) layout.Dimensions {
+						return layoutLoading(gtx, th, currentImage.IsLoading(), func(gtx layout.Context) layout.Dimensions {
+							return layoutImageDisplay(gtx, &currentImage, 24)
+						})
+					})
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layoutMetadata(gtx, th, current.getMeta())
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if !insp.visible {
+						return layout.Dimensions{}
+					}
+					return insp.layout(gtx, th)
 				}),
 			)
 
@@ -82,20 +277,30 @@ func Run(w *app.Window) error {
 	}
 }
 
-// layoutButton renders the fetch button with padding and styling
-func layoutButton(gtx layout.Context, th *material.Theme, btn *widget.Clickable, insetPixels unit.Dp) layout.Dimensions {
+// layoutButton renders a button with padding and styling
+func layoutButton(gtx layout.Context, th *material.Theme, btn *widget.Clickable, label string, insetPixels unit.Dp) layout.Dimensions {
 	inset := layout.UniformInset(insetPixels)
 
-	dims := layoutButtonDims(gtx, inset, th, btn)
+	dims := layoutButtonDims(gtx, inset, th, btn, label)
 
 	return dims
 
 }
 
-func layoutButtonDims(gtx layout.Context, inset layout.Inset, th *material.Theme, btn *widget.Clickable) layout.Dimensions {
+// layoutToggle renders a checkbox styled to match the buttons
+func layoutToggle(gtx layout.Context, th *material.Theme, b *widget.Bool, label string) layout.Dimensions {
+	return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		cb := material.CheckBox(th, b, label)
+		cb.Color = color.NRGBA{R: 248, G: 248, B: 242, A: 255}
+		cb.IconColor = color.NRGBA{R: 189, G: 147, B: 249, A: 255}
+		return cb.Layout(gtx)
+	})
+}
+
+func layoutButtonDims(gtx layout.Context, inset layout.Inset, th *material.Theme, btn *widget.Clickable, label string) layout.Dimensions {
 	return inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 		// Create button with styling
-		button := material.Button(th, btn, "Fetch a Cat")
+		button := material.Button(th, btn, label)
 		button.CornerRadius = unit.Dp(16)
 		button.Background = color.NRGBA{R: 189, G: 147, B: 249, A: 255}
 		button.Color = color.NRGBA{R: 248, G: 248, B: 242, A: 255}
@@ -128,8 +128,7 @@ func TestRun_ErrorHandling(t *testing.T) {
 	t.Run("updates_image_on_success", func(t *testing.T) {
 		// When HandleButtonClick() succeeds
 		// Image is set with currentImage.SetImage(img)
-		// Metadata is available but not currently displayed
-		// (commented out: fmt.Println(meta.Tags))
+		// Metadata is shown under the image by layoutMetadata
 	})
 }
 
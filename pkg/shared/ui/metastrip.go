@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+// metadataDateFormat is how the strip under the image shows when a cat was added
+const metadataDateFormat = "2 Jan 2006"
+
+// metadataLine describes the cat on screen in one line, e.g.
+// "abc123 · cute, orange · added 2 May 2024", leaving out what is unknown
+func metadataLine(meta *api.CatMetadata) string {
+	if meta == nil {
+		return ""
+	}
+	var parts []string
+	if id := meta.GetID(); id != "" {
+		parts = append(parts, id)
+	}
+	if tags := meta.GetTags(); len(tags) > 0 {
+		parts = append(parts, strings.Join(tags, ", "))
+	}
+	if created := meta.GetCreatedAt(); !created.IsZero() {
+		parts = append(parts, "added "+created.Format(metadataDateFormat))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// layoutMetadata draws the metadata line centered under the image, or
+// nothing before the first cat
+func layoutMetadata(gtx layout.Context, th *material.Theme, meta *api.CatMetadata) layout.Dimensions {
+	line := metadataLine(meta)
+	if line == "" {
+		return layout.Dimensions{}
+	}
+	return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.UniformInset(unit.Dp(6)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			lbl := material.Body2(th, line)
+			lbl.Color = inspectorText
+			lbl.MaxLines = 1
+			return lbl.Layout(gtx)
+		})
+	})
+}
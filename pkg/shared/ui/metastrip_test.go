@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+// TestMetadataLine tests the strip under the image leaves out unknown fields
+func TestMetadataLine(t *testing.T) {
+	created := time.Date(2024, 5, 2, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		meta *api.CatMetadata
+		want string
+	}{
+		{"none", nil, ""},
+		{"all", &api.CatMetadata{ID: "abc123", Tags: []string{"cute", "orange"}, CreatedAt: created}, "abc123 · cute, orange · added 2 May 2024"},
+		{"no_tags", &api.CatMetadata{ID: "abc123", CreatedAt: created}, "abc123 · added 2 May 2024"},
+		{"no_date", &api.CatMetadata{ID: "abc123", Tags: []string{"cute"}}, "abc123 · cute"},
+		{"empty", &api.CatMetadata{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, metadataLine(tt.meta), "metadata line")
+		})
+	}
+}
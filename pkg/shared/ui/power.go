@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/bmj2728/catfetch/pkg/shared/power"
+)
+
+// powerCheckEvery is how often the power state is read again
+const powerCheckEvery = time.Minute
+
+// batteryAware pauses prefetching on battery and in power saver mode
+var batteryAware atomic.Bool
+
+func init() {
+	batteryAware.Store(true)
+}
+
+// SetBatteryAware turns pausing background fetches on battery or in power
+// saver mode on or off, it is on by default
+func SetBatteryAware(aware bool) {
+	batteryAware.Store(aware)
+}
+
+// powerMonitor caches the power state between checks. It is only used from
+// the event loop.
+type powerMonitor struct {
+	state   power.State
+	checked time.Time
+	read    func() (power.State, error)
+	now     func() time.Time
+}
+
+func newPowerMonitor() *powerMonitor {
+	return &powerMonitor{read: power.Read, now: time.Now}
+}
+
+// saving reports whether background work should pause to save power
+func (m *powerMonitor) saving() bool {
+	if !batteryAware.Load() {
+		return false
+	}
+	if now := m.now(); m.checked.IsZero() || now.Sub(m.checked) >= powerCheckEvery {
+		m.checked = now
+		state, err := m.read()
+		if err != nil && !errors.Is(err, power.ErrUnsupported) {
+			slog.Debug("Error reading power state", "err", err)
+		}
+		m.state = state
+	}
+	return m.state.Saving()
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/power"
+)
+
+// TestPowerMonitor tests the power state is cached between checks and the
+// override is honored
+func TestPowerMonitor(t *testing.T) {
+	defer SetBatteryAware(true)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	state := power.State{OnBattery: true}
+	var readErr error
+	reads := 0
+	m := newPowerMonitor()
+	m.now = func() time.Time { return now }
+	m.read = func() (power.State, error) {
+		reads++
+		return state, readErr
+	}
+
+	testutil.AssertTrue(t, m.saving(), "saving on battery")
+	state = power.State{}
+	testutil.AssertTrue(t, m.saving(), "cached until the next check")
+	testutil.AssertEqual(t, 1, reads, "read once")
+
+	now = now.Add(powerCheckEvery)
+	testutil.AssertTrue(t, !m.saving(), "plugged in after the next check")
+
+	SetBatteryAware(false)
+	state = power.State{PowerSaver: true}
+	now = now.Add(powerCheckEvery)
+	testutil.AssertTrue(t, !m.saving(), "override ignores power saving")
+
+	SetBatteryAware(true)
+	readErr = errors.New("sysfs gone")
+	state = power.State{}
+	testutil.AssertTrue(t, !m.saving(), "errors count as plugged in")
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+var ErrPresetName = errors.New("preset without a name")
+
+// Preset is a quick button fetching a cat for a mood, such as "Need a
+// laugh" limited to the funny tag
+type Preset struct {
+	Name    string `json:"name"`
+	Tag     string `json:"tag,omitempty"`
+	Mono    bool   `json:"mono,omitempty"`
+	Blurred bool   `json:"blurred,omitempty"`
+}
+
+var (
+	presetsMu sync.Mutex
+	presets   []Preset
+)
+
+// SetPresets sets the mood buttons shown under the filters, there are none
+// by default. It takes effect when Run starts.
+func SetPresets(p []Preset) {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	presets = p
+}
+
+func currentPresets() []Preset {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	return presets
+}
+
+// LoadPresets reads presets from a JSON file holding a list such as
+// [{"name": "Need a laugh", "tag": "funny"}, {"name": "Calm please", "tag": "sleepy", "blurred": true}]
+func LoadPresets(path string) ([]Preset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p []Preset
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("reading presets from %s: %w", path, err)
+	}
+	for i := range p {
+		p[i].Name = strings.TrimSpace(p[i].Name)
+		if p[i].Name == "" {
+			return nil, fmt.Errorf("%w at position %d in %s", ErrPresetName, i+1, path)
+		}
+		p[i].Tag = strings.TrimSpace(p[i].Tag)
+	}
+	return p, nil
+}
+
+// presetBar lays out a button per preset. It is only used from the event
+// loop.
+type presetBar struct {
+	presets []Preset
+	buttons []widget.Clickable
+}
+
+func newPresetBar(p []Preset) *presetBar {
+	return &presetBar{presets: p, buttons: make([]widget.Clickable, len(p))}
+}
+
+// clicked returns the preset whose button was clicked, if any
+func (b *presetBar) clicked(gtx layout.Context) (Preset, bool) {
+	for i := range b.buttons {
+		if b.buttons[i].Clicked(gtx) {
+			return b.presets[i], true
+		}
+	}
+	return Preset{}, false
+}
+
+func (b *presetBar) layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	if len(b.presets) == 0 {
+		return layout.Dimensions{}
+	}
+	children := make([]layout.FlexChild, len(b.presets))
+	for i, p := range b.presets {
+		children[i] = layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.UniformInset(unit.Dp(2)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				btn := material.Button(th, &b.buttons[i], p.Name)
+				btn.TextSize = unit.Sp(12)
+				btn.CornerRadius = unit.Dp(12)
+				btn.Inset = layout.UniformInset(unit.Dp(6))
+				btn.Background = searchFieldBg
+				btn.Color = inspectorText
+				return btn.Layout(gtx)
+			})
+		})
+	}
+	return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, children...)
+	})
+}
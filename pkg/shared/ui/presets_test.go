@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+)
+
+// TestLoadPresets tests reading mood presets from a file
+func TestLoadPresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	err := os.WriteFile(path, []byte(`[
+		{"name": " Need a laugh ", "tag": "funny"},
+		{"name": "Calm please", "tag": "sleepy", "blurred": true},
+		{"name": "Moody", "mono": true}
+	]`), 0o644)
+	testutil.AssertNoError(t, err, "writing presets")
+
+	p, err := LoadPresets(path)
+	testutil.AssertNoError(t, err, "LoadPresets should succeed")
+	testutil.AssertEqual(t, 3, len(p), "presets")
+	testutil.AssertEqual(t, Preset{Name: "Need a laugh", Tag: "funny"}, p[0], "trimmed name")
+	testutil.AssertEqual(t, Preset{Name: "Calm please", Tag: "sleepy", Blurred: true}, p[1], "blurred")
+	testutil.AssertEqual(t, Preset{Name: "Moody", Mono: true}, p[2], "any tag")
+}
+
+// TestLoadPresets_Errors tests bad preset files are rejected
+func TestLoadPresets_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		testutil.AssertNoError(t, os.WriteFile(path, []byte(content), 0o644), "writing presets")
+		return path
+	}
+
+	_, err := LoadPresets(write("unnamed.json", `[{"tag": "funny"}]`))
+	testutil.AssertTrue(t, errors.Is(err, ErrPresetName), "should be unnamed")
+
+	_, err = LoadPresets(write("malformed.json", `{"name": "not a list"}`))
+	testutil.AssertError(t, err, "malformed file")
+
+	_, err = LoadPresets(filepath.Join(dir, "missing.json"))
+	testutil.AssertTrue(t, errors.Is(err, fs.ErrNotExist), "should be missing")
+}
+
+// TestSetPresets tests presets get a button each
+func TestSetPresets(t *testing.T) {
+	defer SetPresets(nil)
+	testutil.AssertEqual(t, 0, len(newPresetBar(currentPresets()).buttons), "none by default")
+
+	SetPresets([]Preset{{Name: "Need a laugh", Tag: "funny"}, {Name: "Calm please"}})
+	bar := newPresetBar(currentPresets())
+	testutil.AssertEqual(t, 2, len(bar.buttons), "a button per preset")
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+// defaultRateLimitWait is how long to hold fetches back after a 429 that
+// didn't say how long to wait
+const defaultRateLimitWait = 10 * time.Second
+
+// rateLimit holds fetches back while the provider asked us to wait, so the
+// user can't keep hammering it. A fetch asked for meanwhile is queued and
+// runs once the wait is over. Fetch goroutines start the wait and the event
+// loop does the rest.
+type rateLimit struct {
+	mu      sync.Mutex
+	until   time.Time
+	waiting bool
+	pending bool
+}
+
+// start holds fetches back for d from now
+func (r *rateLimit) start(now time.Time, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.until = now.Add(d)
+	r.waiting = true
+}
+
+// remaining is how long is left to wait at now, 0 once the wait is over
+func (r *rateLimit) remaining(now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.waiting {
+		return 0
+	}
+	return max(r.until.Sub(now), 0)
+}
+
+// queue asks for a fetch once the wait is over
+func (r *rateLimit) queue() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = true
+}
+
+func (r *rateLimit) queued() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pending
+}
+
+// end reports whether a wait has just finished at now, and whether a fetch
+// was queued during it. Both are only reported once.
+func (r *rateLimit) end(now time.Time) (ended, queued bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.waiting || now.Before(r.until) {
+		return false, false
+	}
+	queued = r.pending
+	r.waiting = false
+	r.pending = false
+	return true, queued
+}
+
+// rateLimitWait reports how long a failed fetch asked us to wait before the
+// next one, which is the Retry-After of a 429 or 503, or defaultRateLimitWait
+// for a 429 without one
+func rateLimitWait(err error) (time.Duration, bool) {
+	var statusErr *api.StatusError
+	if !errors.As(err, &statusErr) {
+		return 0, false
+	}
+	switch {
+	case statusErr.RetryAfter > 0:
+		return statusErr.RetryAfter, true
+	case statusErr.StatusCode == http.StatusTooManyRequests:
+		return defaultRateLimitWait, true
+	default:
+		return 0, false
+	}
+}
+
+// waitMessage counts down the wait in whole seconds, rounded up so it never
+// shows 0s
+func waitMessage(left time.Duration, queued bool) string {
+	secs := int((left + time.Second - 1) / time.Second)
+	msg := fmt.Sprintf("Provider asked us to wait %ds", secs)
+	if queued {
+		msg += ", fetching then"
+	}
+	return msg
+}
+
+// nextTick is how long until the countdown shows another second
+func nextTick(left time.Duration) time.Duration {
+	if rest := left % time.Second; rest > 0 {
+		return rest
+	}
+	return time.Second
+}
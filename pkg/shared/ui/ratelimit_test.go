@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+// TestRateLimit tests a wait counts down and releases a queued fetch once
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	var r rateLimit
+
+	testutil.AssertEqual(t, time.Duration(0), r.remaining(now), "no wait at first")
+	ended, _ := r.end(now)
+	testutil.AssertFalse(t, ended, "nothing to end")
+
+	r.start(now, 42*time.Second)
+	testutil.AssertEqual(t, 42*time.Second, r.remaining(now), "wait started")
+	r.queue()
+	testutil.AssertTrue(t, r.queued(), "fetch queued")
+
+	ended, _ = r.end(now.Add(41 * time.Second))
+	testutil.AssertFalse(t, ended, "still waiting")
+	testutil.AssertEqual(t, time.Second, r.remaining(now.Add(41*time.Second)), "a second left")
+
+	ended, queued := r.end(now.Add(42 * time.Second))
+	testutil.AssertTrue(t, ended, "wait over")
+	testutil.AssertTrue(t, queued, "queued fetch released")
+	testutil.AssertFalse(t, r.queued(), "queue emptied")
+
+	ended, _ = r.end(now.Add(43 * time.Second))
+	testutil.AssertFalse(t, ended, "end reported once")
+	testutil.AssertEqual(t, time.Duration(0), r.remaining(now.Add(43*time.Second)), "no wait left")
+}
+
+// TestRateLimitWait tests which failures hold fetches back
+func TestRateLimitWait(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "retry_after", err: &api.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 42 * time.Second}, want: 42 * time.Second, wantOK: true},
+		{name: "no_retry_after", err: &api.StatusError{StatusCode: http.StatusTooManyRequests}, want: defaultRateLimitWait, wantOK: true},
+		{name: "unavailable_with_wait", err: &api.StatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Minute}, want: time.Minute, wantOK: true},
+		{name: "wrapped", err: fmt.Errorf("request abc: %w", &api.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 5 * time.Second}), want: 5 * time.Second, wantOK: true},
+		{name: "unavailable", err: &api.StatusError{StatusCode: http.StatusServiceUnavailable}},
+		{name: "network", err: api.ErrNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rateLimitWait(tt.err)
+			testutil.AssertEqual(t, tt.wantOK, ok, "rate limited")
+			testutil.AssertEqual(t, tt.want, got, "wait")
+		})
+	}
+}
+
+// TestWaitMessage tests the countdown text and when it changes
+func TestWaitMessage(t *testing.T) {
+	testutil.AssertEqual(t, "Provider asked us to wait 42s", waitMessage(42*time.Second, false), "whole seconds")
+	testutil.AssertEqual(t, "Provider asked us to wait 42s", waitMessage(41300*time.Millisecond, false), "rounded up")
+	testutil.AssertEqual(t, "Provider asked us to wait 1s, fetching then", waitMessage(time.Millisecond, true), "queued fetch")
+
+	testutil.AssertEqual(t, 300*time.Millisecond, nextTick(41300*time.Millisecond), "until the next second")
+	testutil.AssertEqual(t, time.Second, nextTick(42*time.Second), "a whole second")
+}
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+	"github.com/bmj2728/catfetch/pkg/shared/imgutil"
+	"github.com/bmj2728/catfetch/pkg/shared/openext"
+	"github.com/bmj2728/catfetch/pkg/shared/savedialog"
+	"github.com/bmj2728/catfetch/pkg/shared/savename"
+)
+
+// saveTemplate names saved cats after their ID, such as abc123.jpeg. Cats
+// without an ID get savename.DefaultTemplate.
+const saveTemplate = "{id}.{ext}"
+
+var errNothingToSave = errors.New("no cat to save")
+
+// askSavePath shows the save dialog, tests replace it
+var askSavePath = func(defaultPath string) (string, error) {
+	return savedialog.Ask("Save cat", defaultPath)
+}
+
+var (
+	saveDirMu sync.Mutex
+	saveDir   string
+	flattenBg color.Color // transparent PNGs are flattened onto it when set
+	afterSave openext.Action
+)
+
+// SetSaveDir changes the folder the save dialog starts in, and where cats
+// are saved when there's no dialog, the catfetch folder in the user's
+// pictures by default
+func SetSaveDir(dir string) {
+	saveDirMu.Lock()
+	defer saveDirMu.Unlock()
+	saveDir = dir
+}
+
+// SetSaveFlatten makes the Save button composite transparent PNGs over bg,
+// for viewers that show transparency as black. Nil, the default, saves them
+// as served.
+func SetSaveFlatten(bg color.Color) {
+	saveDirMu.Lock()
+	defer saveDirMu.Unlock()
+	flattenBg = bg
+}
+
+func currentFlatten() color.Color {
+	saveDirMu.Lock()
+	defer saveDirMu.Unlock()
+	return flattenBg
+}
+
+// SetAfterSave sets what happens to a cat once it's saved, such as showing
+// it in the file manager. Nothing happens by default.
+func SetAfterSave(a openext.Action) {
+	saveDirMu.Lock()
+	defer saveDirMu.Unlock()
+	afterSave = a
+}
+
+func currentAfterSave() openext.Action {
+	saveDirMu.Lock()
+	defer saveDirMu.Unlock()
+	return afterSave
+}
+
+// afterSaving applies the action set with SetAfterSave to a saved cat
+func afterSaving(path string) {
+	if err := currentAfterSave().Do(path); err != nil {
+		slog.Warn("Error acting on saved cat", "path", path, "err", err)
+	}
+}
+
+// currentSaveDir returns the configured folder, or ~/Pictures/catfetch
+func currentSaveDir() (string, error) {
+	saveDirMu.Lock()
+	dir := saveDir
+	saveDirMu.Unlock()
+	if dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Pictures", "catfetch"), nil
+}
+
+// saveCurrent asks where to save a cat with the save dialog, starting in
+// the configured folder with the cat's name filled in, and writes it there.
+// Without a dialog the cat is saved into the folder under a free name. A
+// cancelled dialog returns savedialog.ErrCancelled.
+func saveCurrent(data []byte, format string, meta *api.CatMetadata) (string, error) {
+	if len(data) == 0 {
+		return "", errNothingToSave
+	}
+	dir, err := currentSaveDir()
+	if err != nil {
+		return "", err
+	}
+	data, err = flattenPNG(data, format, currentFlatten())
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	name, err := saveName(format, meta, now)
+	if err != nil {
+		return "", err
+	}
+
+	// the dialog can only start in a folder that exists
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path, err := askSavePath(filepath.Join(dir, name))
+	switch {
+	case errors.Is(err, savedialog.ErrUnsupported):
+		return saveCat(dir, data, format, meta, now)
+	case err != nil:
+		return "", err
+	}
+	// the dialog asked before replacing a file
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// flattenPNG composites a transparent PNG over bg and encodes it again.
+// Other formats, opaque PNGs and a nil bg leave data as it is; GIFs keep
+// their transparency rather than lose their animation.
+func flattenPNG(data []byte, format string, bg color.Color) ([]byte, error) {
+	if bg == nil || format != "png" {
+		return data, nil
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if !imgutil.HasAlpha(img) {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, imgutil.Flatten(img, bg)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// saveFailedMessage describes a failed save without the raw Go error
+func saveFailedMessage(err error) string {
+	switch {
+	case errors.Is(err, errNothingToSave):
+		return "Nothing to save yet, fetch a cat first"
+	case errors.Is(err, fs.ErrPermission):
+		return "Save failed: permission denied"
+	default:
+		return "Save failed"
+	}
+}
+
+// saveCat writes the bytes of a cat as served into dir, named after its ID
+// with the extension of the detected format. An existing file is never
+// overwritten, a numbered name is picked instead. It returns the path
+// written.
+func saveCat(dir string, data []byte, format string, meta *api.CatMetadata, now time.Time) (string, error) {
+	if len(data) == 0 {
+		return "", errNothingToSave
+	}
+	name, err := saveName(format, meta, now)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	f, err := savename.Create(dir, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// saveName names a cat after its ID with the extension of its format
+func saveName(format string, meta *api.CatMetadata, now time.Time) (string, error) {
+	fields := savename.Fields{Ext: format, Time: now}
+	if meta != nil {
+		fields.ID = meta.GetID()
+		fields.Tags = meta.GetTags()
+	}
+	tmpl := saveTemplate
+	if fields.ID == "" {
+		tmpl = savename.DefaultTemplate
+	}
+	return savename.Render(tmpl, fields)
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+	"github.com/bmj2728/catfetch/pkg/shared/openext"
+	"github.com/bmj2728/catfetch/pkg/shared/savedialog"
+)
+
+// TestSaveCat tests cats are saved as served, named after their ID
+func TestSaveCat(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cats")
+	data := []byte("not really a jpeg")
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	meta := &api.CatMetadata{ID: "abc123", Tags: []string{"cute"}}
+
+	for _, want := range []string{"abc123.jpeg", "abc123-1.jpeg"} {
+		path, err := saveCat(dir, data, "jpeg", meta, at)
+		testutil.AssertNoError(t, err, "saveCat should succeed")
+		testutil.AssertEqual(t, filepath.Join(dir, want), path, "path")
+
+		saved, err := os.ReadFile(path)
+		testutil.AssertNoError(t, err, "reading the saved cat")
+		testutil.AssertTrue(t, bytes.Equal(data, saved), "original bytes")
+	}
+
+	path, err := saveCat(dir, data, "png", &api.CatMetadata{}, at)
+	testutil.AssertNoError(t, err, "saveCat should succeed without an ID")
+	testutil.AssertEqual(t, filepath.Join(dir, "2024-05-01.png"), path, "default name")
+}
+
+// TestSaveCat_NothingToSave tests saving before the first cat
+func TestSaveCat_NothingToSave(t *testing.T) {
+	_, err := saveCat(t.TempDir(), nil, "", nil, time.Now())
+	testutil.AssertTrue(t, errors.Is(err, errNothingToSave), "should be nothing to save")
+	testutil.AssertEqual(t, "Nothing to save yet, fetch a cat first", saveFailedMessage(err), "message")
+}
+
+// TestSaveCurrent tests cats are written where the save dialog says, and
+// into the save folder when there's no dialog
+func TestSaveCurrent(t *testing.T) {
+	saved := askSavePath
+	defer func() { askSavePath = saved }()
+	dir := filepath.Join(t.TempDir(), "cats")
+	SetSaveDir(dir)
+	defer SetSaveDir("")
+	data := []byte("not really a jpeg")
+	meta := &api.CatMetadata{ID: "abc123"}
+
+	picked := filepath.Join(t.TempDir(), "mine.jpeg")
+	var asked string
+	askSavePath = func(defaultPath string) (string, error) {
+		asked = defaultPath
+		return picked, nil
+	}
+	path, err := saveCurrent(data, "jpeg", meta)
+	testutil.AssertNoError(t, err, "saveCurrent should succeed")
+	testutil.AssertEqual(t, filepath.Join(dir, "abc123.jpeg"), asked, "dialog starts with the cat's name")
+	testutil.AssertEqual(t, picked, path, "saved where picked")
+	written, err := os.ReadFile(picked)
+	testutil.AssertNoError(t, err, "reading the saved cat")
+	testutil.AssertTrue(t, bytes.Equal(data, written), "original bytes")
+
+	askSavePath = func(string) (string, error) { return "", savedialog.ErrCancelled }
+	_, err = saveCurrent(data, "jpeg", meta)
+	testutil.AssertTrue(t, errors.Is(err, savedialog.ErrCancelled), "should be cancelled")
+	_, err = os.Stat(filepath.Join(dir, "abc123.jpeg"))
+	testutil.AssertTrue(t, errors.Is(err, os.ErrNotExist), "nothing saved when cancelled")
+
+	askSavePath = func(string) (string, error) { return "", savedialog.ErrUnsupported }
+	path, err = saveCurrent(data, "jpeg", meta)
+	testutil.AssertNoError(t, err, "saveCurrent should succeed without a dialog")
+	testutil.AssertEqual(t, filepath.Join(dir, "abc123.jpeg"), path, "saved into the folder")
+
+	_, err = saveCurrent(nil, "", nil)
+	testutil.AssertTrue(t, errors.Is(err, errNothingToSave), "should be nothing to save")
+}
+
+// TestAfterSaving tests the chosen action runs on saved cats, and nothing
+// happens by default
+func TestAfterSaving(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs touch")
+	}
+	defer SetAfterSave(openext.Action{})
+	dir := t.TempDir()
+
+	afterSaving(filepath.Join(dir, "ignored.jpeg"))
+	testutil.AssertEqual(t, openext.ActionNone, currentAfterSave().Kind, "nothing by default")
+
+	SetAfterSave(openext.Action{Kind: openext.ActionRun, Command: "touch {path}.seen"})
+	afterSaving(filepath.Join(dir, "abc123.jpeg"))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "abc123.jpeg.seen")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("command should have run on the saved cat")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSetSaveDir tests the configured folder replaces the default
+func TestSetSaveDir(t *testing.T) {
+	defer SetSaveDir("")
+
+	dir, err := currentSaveDir()
+	testutil.AssertNoError(t, err, "default folder")
+	testutil.AssertEqual(t, "catfetch", filepath.Base(dir), "default folder name")
+
+	SetSaveDir("/tmp/cats")
+	dir, err = currentSaveDir()
+	testutil.AssertNoError(t, err, "configured folder")
+	testutil.AssertEqual(t, "/tmp/cats", dir, "configured folder")
+}
+
+// TestCatState_Saveable tests the bytes of the cat on screen are kept
+func TestCatState_Saveable(t *testing.T) {
+	var s catState
+	data, _, _ := s.saveable()
+	testutil.AssertEqual(t, 0, len(data), "nothing before the first cat")
+
+	s.setResult(&api.FetchResult{Bytes: []byte("cat"), Format: "gif", Metadata: &api.CatMetadata{ID: "abc"}})
+	data, format, meta := s.saveable()
+	testutil.AssertEqual(t, "cat", string(data), "bytes")
+	testutil.AssertEqual(t, "gif", format, "format")
+	testutil.AssertEqual(t, "abc", meta.GetID(), "metadata")
+}
+
+// TestFlattenPNG tests transparent PNGs are flattened only when asked
+func TestFlattenPNG(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	testutil.AssertNoError(t, png.Encode(&buf, src), "encode")
+	transparent := buf.Bytes()
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+	data, err := flattenPNG(transparent, "png", nil)
+	testutil.AssertNoError(t, err, "no background")
+	testutil.AssertTrue(t, bytes.Equal(transparent, data), "kept as served without a background")
+
+	data, err = flattenPNG(transparent, "gif", white)
+	testutil.AssertNoError(t, err, "other format")
+	testutil.AssertTrue(t, bytes.Equal(transparent, data), "only PNGs are flattened")
+
+	data, err = flattenPNG(transparent, "png", white)
+	testutil.AssertNoError(t, err, "flatten")
+	img, err := png.Decode(bytes.NewReader(data))
+	testutil.AssertNoError(t, err, "decode flattened")
+	testutil.AssertEqual(t, color.Color(color.NRGBA{R: 255, A: 255}), color.NRGBAModel.Convert(img.At(0, 0)), "opaque pixel kept")
+	testutil.AssertEqual(t, color.Color(white), color.NRGBAModel.Convert(img.At(1, 0)), "transparent pixel on the background")
+
+	_, err = flattenPNG([]byte("not a png"), "png", white)
+	testutil.AssertError(t, err, "invalid PNG")
+}
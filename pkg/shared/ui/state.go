@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"sync"
+
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+// catState holds the metadata and downloaded bytes of the cat on screen,
+// it is written by fetch goroutines and read while laying out frames
+type catState struct {
+	mu     sync.Mutex
+	meta   *api.CatMetadata
+	data   []byte // the image as served, for saving
+	format string
+}
+
+// setResult replaces the cat on screen
+func (s *catState) setResult(res *api.FetchResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.meta = res.Metadata
+	s.data = res.Bytes
+	s.format = res.Format
+}
+
+// saveable returns what saving the cat on screen needs, data is nil before
+// the first cat
+func (s *catState) saveable() (data []byte, format string, meta *api.CatMetadata) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data, s.format, s.meta
+}
+
+func (s *catState) getMeta() *api.CatMetadata {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.meta
+}
+
+// imageSize returns the downloaded size of the cat on screen, 0 if unknown
+func (s *catState) imageSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.meta == nil {
+		return 0
+	}
+	return s.meta.GetSize()
+}
@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+// maxSuggestions bounds the tags offered under the search box
+const maxSuggestions = 5
+
+// tagsTimeout bounds loading the tags the search box offers
+const tagsTimeout = 30 * time.Second
+
+// typingPause is how long typing has to stop before cats are prefetched for
+// the tag in the field
+const typingPause = 400 * time.Millisecond
+
+// searchFieldWidth is the width of the tag field
+const searchFieldWidth = unit.Dp(240)
+
+var searchFieldBg = color.NRGBA{R: 68, G: 71, B: 90, A: 255}
+
+// tagSearch limits fetches to a tag typed into a text field, offering the
+// provider's tags that match as buttons. The tag applies on Enter, on
+// picking a suggestion, and when fetching. The tag list is loaded in the
+// background, everything else only runs on the event loop.
+type tagSearch struct {
+	editor  widget.Editor
+	picks   [maxSuggestions]widget.Clickable
+	matches []string
+	tag     string    // the tag fetches are limited to
+	edited  time.Time // when the text in the field last changed
+
+	mu   sync.Mutex
+	tags api.CAASTags
+}
+
+func newTagSearch() *tagSearch {
+	return &tagSearch{editor: widget.Editor{SingleLine: true, Submit: true}}
+}
+
+func (s *tagSearch) setTags(tags api.CAASTags) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags = tags
+}
+
+func (s *tagSearch) allTags() api.CAASTags {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tags
+}
+
+// load fetches the tags from the current provider
+func (s *tagSearch) load() {
+	ctx, cancel := context.WithTimeout(context.Background(), tagsTimeout)
+	defer cancel()
+	tags, err := currentProvider().ListTags(ctx)
+	if err != nil {
+		slog.Warn("Error loading tags", "err", err)
+		return
+	}
+	s.setTags(tags)
+}
+
+// apply limits fetches to the text in the field, an empty field lifts the limit
+func (s *tagSearch) apply() {
+	s.tag = strings.TrimSpace(s.editor.Text())
+}
+
+// set puts tag in the field and applies it
+func (s *tagSearch) set(tag string) {
+	s.editor.SetText(tag)
+	s.editor.SetCaret(s.editor.Len(), s.editor.Len())
+	s.apply()
+}
+
+// update handles typing and picked suggestions, reporting whether there
+// was any
+func (s *tagSearch) update(gtx layout.Context) bool {
+	active := false
+	for {
+		e, ok := s.editor.Update(gtx)
+		if !ok {
+			break
+		}
+		active = true
+		switch e.(type) {
+		case widget.ChangeEvent:
+			s.edited = gtx.Now
+		case widget.SubmitEvent:
+			s.apply()
+		}
+	}
+	for i, match := range s.matches {
+		if s.picks[i].Clicked(gtx) {
+			active = true
+			s.set(match)
+		}
+	}
+
+	// nothing to suggest once the tag in the field is the one applied
+	s.matches = nil
+	if text := strings.TrimSpace(s.editor.Text()); text != s.tag {
+		s.matches = suggestTags(s.allTags(), text, maxSuggestions)
+	}
+	return active
+}
+
+// candidate returns the tag in the field once typing has paused, when it is
+// one of the provider's tags and not applied yet, so cats for it can be
+// prefetched before it is
+func (s *tagSearch) candidate(now time.Time) (string, bool) {
+	if s.settling(now) > 0 {
+		return "", false
+	}
+	text := strings.TrimSpace(s.editor.Text())
+	if text == "" || text == s.tag || !slices.Contains(s.allTags(), text) {
+		return "", false
+	}
+	return text, true
+}
+
+// settling is how long until typing counts as paused, 0 once it has
+func (s *tagSearch) settling(now time.Time) time.Duration {
+	return max(s.edited.Add(typingPause).Sub(now), 0)
+}
+
+// suggestTags returns up to limit tags starting with query, followed by
+// those containing it elsewhere, ignoring case. An empty query or an exact
+// match suggests nothing.
+func suggestTags(tags []string, query string, limit int) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	var prefixed, contained []string
+	for _, tag := range tags {
+		lower := strings.ToLower(tag)
+		switch {
+		case lower == query:
+			return nil
+		case strings.HasPrefix(lower, query):
+			prefixed = append(prefixed, tag)
+		case strings.Contains(lower, query):
+			contained = append(contained, tag)
+		}
+	}
+
+	matches := append(prefixed, contained...)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// searchFailedMessage is failedMessage, except that a tag which matched no
+// cats says so
+func searchFailedMessage(err error, tag string) string {
+	var statusErr *api.StatusError
+	noCats := errors.Is(err, api.ErrNoCat) || errors.Is(err, api.ErrInvalidTag) ||
+		errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+	if tag != "" && noCats {
+		return "No cats for that tag"
+	}
+	return failedMessage(err)
+}
+
+// layout draws the search field with the suggestions under it
+func (s *tagSearch) layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return s.layoutField(gtx, th)
+				})
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if len(s.matches) == 0 {
+					return layout.Dimensions{}
+				}
+				children := make([]layout.FlexChild, len(s.matches))
+				for i, match := range s.matches {
+					children[i] = layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return layout.UniformInset(unit.Dp(2)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							btn := material.Button(th, &s.picks[i], match)
+							btn.TextSize = unit.Sp(12)
+							btn.CornerRadius = unit.Dp(12)
+							btn.Inset = layout.UniformInset(unit.Dp(6))
+							btn.Background = searchFieldBg
+							btn.Color = inspectorText
+							return btn.Layout(gtx)
+						})
+					})
+				}
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, children...)
+			}),
+		)
+	})
+}
+
+// layoutField draws the text field on a rounded background
+func (s *tagSearch) layoutField(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	gtx.Constraints.Max.X = min(gtx.Dp(searchFieldWidth), gtx.Constraints.Max.X)
+
+	return layout.Stack{}.Layout(gtx,
+		layout.Expanded(func(gtx layout.Context) layout.Dimensions {
+			defer clip.UniformRRect(image.Rectangle{Max: gtx.Constraints.Min}, gtx.Dp(8)).Push(gtx.Ops).Pop()
+			paint.Fill(gtx.Ops, searchFieldBg)
+			return layout.Dimensions{Size: gtx.Constraints.Min}
+		}),
+		layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				ed := material.Editor(th, &s.editor, "Filter by tag")
+				ed.Color = inspectorText
+				ed.HintColor = color.NRGBA{R: 98, G: 114, B: 164, A: 255}
+				return ed.Layout(gtx)
+			})
+		}),
+	)
+}
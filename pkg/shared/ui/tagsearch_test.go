@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/catfetch/internal/testutil"
+	"github.com/bmj2728/catfetch/pkg/shared/api"
+)
+
+// TestSuggestTags tests prefix matches come before other matches
+func TestSuggestTags(t *testing.T) {
+	tags := []string{"cute", "orange", "Cute Kitten", "acute", "sleepy", "cuddly"}
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{"prefix_first", "cu", 5, []string{"cute", "Cute Kitten", "cuddly", "acute"}},
+		{"ignores_case", "ORA", 5, []string{"orange"}},
+		{"limited", "cu", 2, []string{"cute", "Cute Kitten"}},
+		{"exact_match", "sleepy", 5, nil},
+		{"empty", "  ", 5, nil},
+		{"no_match", "dog", 5, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestTags(tags, tt.query, tt.limit)
+			testutil.AssertEqual(t, fmt.Sprint(tt.want), fmt.Sprint(got), "suggestions")
+		})
+	}
+}
+
+// TestSearchFailedMessage tests a tag without cats gets its own message
+func TestSearchFailedMessage(t *testing.T) {
+	notFound := fmt.Errorf("fetching: %w", &api.StatusError{StatusCode: http.StatusNotFound})
+	tests := []struct {
+		name string
+		err  error
+		tag  string
+		want string
+	}{
+		{"not_found", notFound, "cute", "No cats for that tag"},
+		{"no_cat", api.ErrNoCat, "cute", "No cats for that tag"},
+		{"invalid_tag", api.ErrInvalidTag, "dog", "No cats for that tag"},
+		{"no_tag", notFound, "", failedMessage(notFound)},
+		{"other_error", api.ErrNetwork, "cute", failedMessage(api.ErrNetwork)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, searchFailedMessage(tt.err, tt.tag), "message")
+		})
+	}
+}
+
+// TestFetchSettings_Tag tests the tag limits fetches
+func TestFetchSettings_Tag(t *testing.T) {
+	testutil.AssertEqual(t, len(fetchSettings{}.options())+1, len(fetchSettings{tag: "cute"}.options()), "tag option added")
+}
+
+// TestTagSearch_Apply tests the typed tag applies trimmed
+func TestTagSearch_Apply(t *testing.T) {
+	s := newTagSearch()
+	s.setTags(api.CAASTags{"cute"})
+	testutil.AssertEqual(t, 1, len(s.allTags()), "tags kept")
+
+	s.editor.SetText("  cute ")
+	s.apply()
+	testutil.AssertEqual(t, "cute", s.tag, "applied tag")
+
+	s.editor.SetText("")
+	s.apply()
+	testutil.AssertEqual(t, "", s.tag, "cleared")
+}
+
+// TestTagSearch_Candidate tests a typed tag is offered for prefetching once
+// typing pauses
+func TestTagSearch_Candidate(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	s := newTagSearch()
+	s.setTags(api.CAASTags{"cute", "orange"})
+
+	s.editor.SetText("orange")
+	s.edited = now
+	_, ok := s.candidate(now.Add(typingPause / 2))
+	testutil.AssertFalse(t, ok, "still typing")
+	testutil.AssertEqual(t, typingPause/2, s.settling(now.Add(typingPause/2)), "time until typing counts as paused")
+
+	tag, ok := s.candidate(now.Add(typingPause))
+	testutil.AssertTrue(t, ok, "typing paused on a known tag")
+	testutil.AssertEqual(t, "orange", tag, "candidate tag")
+	testutil.AssertEqual(t, time.Duration(0), s.settling(now.Add(typingPause)), "paused")
+
+	s.apply()
+	_, ok = s.candidate(now.Add(typingPause))
+	testutil.AssertFalse(t, ok, "applied tag needs no warming")
+
+	s.editor.SetText("oran")
+	_, ok = s.candidate(now.Add(typingPause))
+	testutil.AssertFalse(t, ok, "not a known tag")
+}